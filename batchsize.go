@@ -0,0 +1,91 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Bounds of the adaptive byte budget and the weight of the newest sample in
+// each worker's throughput average.
+const (
+	minBatchBytes = 64 << 10
+	maxBatchBytes = 256 << 20
+	rateWeight    = 0.3
+)
+
+// batchSizer decides when scan cuts a batch by its size in bytes. With
+// --target-latency it adapts the budget to the commit throughput the workers
+// observe, so a batch takes about the target to commit whatever the row width.
+type batchSizer struct {
+	budget int64 // bytes, read by scan without locking
+
+	mu     sync.Mutex
+	target time.Duration
+	rates  []float64 // per worker average bytes/sec, 0 before its first batch
+}
+
+// sizer is nil when batches are cut by --batch-size rows.
+var sizer *batchSizer
+
+func newBatchSizer() *batchSizer {
+	if batchBytes <= 0 {
+		if targetLatency > 0 {
+			batchBytes = 4 << 20
+		} else {
+			return nil
+		}
+	}
+	return &batchSizer{budget: int64(batchBytes), target: targetLatency, rates: make([]float64, workers*len(targets))}
+}
+
+func (s *batchSizer) limit() int64 {
+	return atomic.LoadInt64(&s.budget)
+}
+
+// full reports whether a batch of this many bytes should be dispatched.
+func (s *batchSizer) full(bytes int64) bool {
+	return bytes >= s.limit()
+}
+
+// observe records how long worker took to commit a batch and retunes the
+// budget to target latency times the mean worker throughput. A step changes
+// the budget at most by half or double, so one slow commit does not swing it.
+func (s *batchSizer) observe(worker int, bytes int64, took time.Duration) {
+	if s.target <= 0 || took <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rate := float64(bytes) / took.Seconds()
+	if s.rates[worker] == 0 {
+		s.rates[worker] = rate
+	} else {
+		s.rates[worker] = rateWeight*rate + (1-rateWeight)*s.rates[worker]
+	}
+
+	var sum float64
+	var n int
+	for _, r := range s.rates {
+		if r > 0 {
+			sum += r
+			n++
+		}
+	}
+	next := int64(s.target.Seconds() * sum / float64(n))
+	cur := s.limit()
+	if next > 2*cur {
+		next = 2 * cur
+	}
+	if next < cur/2 {
+		next = cur / 2
+	}
+	if next < minBatchBytes {
+		next = minBatchBytes
+	}
+	if next > maxBatchBytes {
+		next = maxBatchBytes
+	}
+	atomic.StoreInt64(&s.budget, next)
+}
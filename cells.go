@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// cellsStage is the name of the stage maintaining the cell dimension.
+const cellsStage = "cells"
+
+// maxReportedRenames limits how many renames a run prints.
+const maxReportedRenames = 10
+
+// cellTableDDL creates the cell dimension. A cell is identified by its network
+// element and cell id, e.g. (rnc, ci); each name it has had is a version, and
+// only the latest is current. The unique index also covers tables created
+// before it was added.
+func cellTableDDL(table string) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
+	unique_id text NOT NULL,
+	%[2]s text,
+	cellname text,
+	%[3]s text,
+	first_seen timestamp NOT NULL,
+	last_seen timestamp NOT NULL,
+	is_current boolean NOT NULL DEFAULT true,
+	opened_run text,
+	closed_run text
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "%[4]s_version_key" ON %[1]s (%[2]s, %[3]s, first_seen);`, table, tech.ne(), tech.cellID(), cellTable)
+}
+
+// reportCells prints the cells this run added, renamed, and the cells of the
+// loaded network elements that were missing from the load.
+func reportCells(db *sqlx.DB, params stageParams) {
+	var added, disappeared int64
+	var renamed []struct {
+		OldName string `db:"old_name"`
+		NewName string `db:"new_name"`
+	}
+
+	err := db.Get(&added, fmt.Sprintf(`SELECT count(*) FROM %[1]s n WHERE n.opened_run = $1
+		AND NOT EXISTS (SELECT 1 FROM %[1]s o WHERE o.%[2]s = n.%[2]s AND o.%[3]s = n.%[3]s AND o.closed_run = $1)`, params.Cells, params.NE, params.CellID), runID)
+	if err != nil {
+		panic(err)
+	}
+	err = db.Select(&renamed, fmt.Sprintf(`SELECT o.cellname AS old_name, n.cellname AS new_name FROM %[1]s n
+		JOIN %[1]s o ON o.%[2]s = n.%[2]s AND o.%[3]s = n.%[3]s AND o.closed_run = $1
+		WHERE n.opened_run = $1 ORDER BY n.%[2]s, n.%[3]s`, params.Cells, params.NE, params.CellID), runID)
+	if err != nil {
+		panic(err)
+	}
+	err = db.Get(&disappeared, fmt.Sprintf(`SELECT count(*) FROM %[1]s WHERE is_current AND last_seen < $1
+		AND %[3]s IN (SELECT DISTINCT %[3]s FROM %[2]s)`, params.Cells, params.Staging, params.NE), params.From)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("[CELLS] %d new, %d renamed, %d disappeared\n", added, len(renamed), disappeared)
+	for i, r := range renamed {
+		if i == maxReportedRenames {
+			fmt.Printf("[CELLS] ... %d more renames\n", len(renamed)-i)
+			break
+		}
+		fmt.Printf("[CELLS] renamed %s -> %s\n", r.OldName, r.NewName)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+// huawei2GKeys are the leading columns of the 2G tables, in COPY order. The
+// export's network element is the BSC and cells are identified by CI.
+var huawei2GKeys = []column{
+	{"resulttime", "timestamp"},
+	{"unique_id", "text"},
+	{"bsc", "text"},
+	{"cellname", "text"},
+	{"ci", "text"},
+}
+
+// huawei2GCounters is the built-in core set of BSC cell counters. Load the
+// full export with --catalogue.
+var huawei2GCounters = []column{
+	{"ImmAssignReq", "bigint"},
+	{"ImmAssignSucc", "bigint"},
+	{"SDCCHSeizureReq", "bigint"},
+	{"SDCCHSeizureSucc", "bigint"},
+	{"SDCCHCongestion", "bigint"},
+	{"SDCCHDrops", "bigint"},
+	{"SDCCHTrafficErlang", "double precision"},
+	{"SDCCHAvailableMean", "double precision"},
+	{"AssignmentReq", "bigint"},
+	{"AssignmentSucc", "bigint"},
+	{"TCHSeizureReq", "bigint"},
+	{"TCHSeizureSucc", "bigint"},
+	{"TCHCongestion", "bigint"},
+	{"TCHDrops", "bigint"},
+	{"TCHTrafficErlang", "double precision"},
+	{"TCHHalfRateTrafficErlang", "double precision"},
+	{"TCHAvailableMean", "double precision"},
+	{"HOOutAtt", "bigint"},
+	{"HOOutSucc", "bigint"},
+	{"HOInAtt", "bigint"},
+	{"HOInSucc", "bigint"},
+	{"PDCHAvailableMean", "double precision"},
+	{"GPRSDLDatakbits", "double precision"},
+	{"EDGEDLDatakbits", "double precision"},
+	{"CellUnavailDuration", "bigint"},
+}
+
+var huawei2GKPIs = []kpi{
+	{"sdcch_sr", "100 * SDCCHSeizureSucc / SDCCHSeizureReq"},
+	{"sdcch_drop_rate", "100 * SDCCHDrops / SDCCHSeizureSucc"},
+	{"tch_sr", "100 * TCHSeizureSucc / TCHSeizureReq"},
+	{"tch_congestion_rate", "100 * TCHCongestion / TCHSeizureReq"},
+	{"tch_drop_rate", "100 * TCHDrops / TCHSeizureSucc"},
+	{"cssr", "100 * SDCCHSeizureSucc / SDCCHSeizureReq * (1 - SDCCHDrops / SDCCHSeizureSucc) * TCHSeizureSucc / TCHSeizureReq"},
+	{"ho_sr", "100 * HOOutSucc / HOOutAtt"},
+	{"tch_traffic_erl", "TCHTrafficErlang * 3600 / period_seconds"},
+	{"availability", "100 * (period_seconds - CellUnavailDuration) / period_seconds"},
+}
+
+var huawei2GSuccessAttemptPairs = [][2]string{
+	{"ImmAssignSucc", "ImmAssignReq"},
+	{"SDCCHSeizureSucc", "SDCCHSeizureReq"},
+	{"AssignmentSucc", "AssignmentReq"},
+	{"TCHSeizureSucc", "TCHSeizureReq"},
+	{"HOOutSucc", "HOOutAtt"},
+	{"HOInSucc", "HOInAtt"},
+}
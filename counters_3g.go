@@ -0,0 +1,648 @@
+package main
+
+// huawei3GKeys are the leading columns of counter_3g_lastday and
+// counter_3g_hourly, in COPY order: resulttime, the derived UNIQUE_ID, then
+// the cell identity fields of the export.
+var huawei3GKeys = []column{
+	{"resulttime", "timestamp"},
+	{"unique_id", "text"},
+	{"rnc", "text"},
+	{"cellname", "text"},
+	{"ci", "text"},
+}
+
+// huawei3GCounters is the built-in counter catalogue, in export order. Gauges
+// (means, maxima, throughput and traffic volumes) are double precision, event
+// counters are bigint.
+var huawei3GCounters = []column{
+	{"VSRRCSetupConnEstab", "bigint"},
+	{"RRCSuccConnEstabsum", "bigint"},
+	{"RRCAttConnEstabOrgConvCall", "bigint"},
+	{"RRCAttConnEstabOrgStrCall", "bigint"},
+	{"RRCAttConnEstabOrgInterCall", "bigint"},
+	{"RRCAttConnEstabOrgBkgCall", "bigint"},
+	{"RRCAttConnEstabOrgSubCall", "bigint"},
+	{"RRCAttConnEstabTmConvCall", "bigint"},
+	{"RRCAttConnEstabTmStrCall", "bigint"},
+	{"RRCAttConnEstabTmInterCall", "bigint"},
+	{"RRCAttConnEstabTmBkgCall", "bigint"},
+	{"RRCAttConnEstabEmgCall", "bigint"},
+	{"RRCAttConnEstabOrgHhPrSig", "bigint"},
+	{"RRCAttConnEstabOrgLwPrSig", "bigint"},
+	{"RRCAttConnEstabCallReEst", "bigint"},
+	{"RRCAttConnEstabTmHhPrSig", "bigint"},
+	{"RRCAttConnEstabTmLwPrSig", "bigint"},
+	{"RRCAttConnEstabUnknown", "bigint"},
+	{"RRCSuccConnEstabOrgConvCall", "bigint"},
+	{"RRCSuccConnEstabOrgStrCall", "bigint"},
+	{"RRCSuccConnEstabOrgInterCall", "bigint"},
+	{"RRCSuccConnEstabOrgBkgCall", "bigint"},
+	{"RRCSuccConnEstabOrgSubCall", "bigint"},
+	{"RRCSuccConnEstabTmConvCall", "bigint"},
+	{"RRCSuccConnEstabTmStrCall", "bigint"},
+	{"RRCSuccConnEstabTmItrCall", "bigint"},
+	{"RRCSuccConnEstabTmBkgCall", "bigint"},
+	{"RRCSuccConnEstabEmgCall", "bigint"},
+	{"RRCSuccConnEstabOrgHhPrSig", "bigint"},
+	{"RRCSuccConnEstabOrgLwPrSig", "bigint"},
+	{"RRCSuccConnEstabCallReEst", "bigint"},
+	{"RRCSuccConnEstabTmHhPrSig", "bigint"},
+	{"RRCSuccConnEstabTmLwPrSig", "bigint"},
+	{"RRCSuccConnEstabUnkown", "bigint"},
+	{"VSRRCEstabDRDOutAtt", "bigint"},
+	{"VSRRCAttConnEstabSum", "bigint"},
+	{"VSRRCEstabDRDIn", "bigint"},
+	{"VSTPUE0", "bigint"},
+	{"VSTPUE1", "bigint"},
+	{"VSTPUE2", "bigint"},
+	{"VSTPUE3", "bigint"},
+	{"VSTPUE4", "bigint"},
+	{"VSTPUE5", "bigint"},
+	{"VSTPUE69", "bigint"},
+	{"VSTPUE1625", "bigint"},
+	{"VSTPUE2635", "bigint"},
+	{"VSTPUE3655", "bigint"},
+	{"VSTPUEMore55", "bigint"},
+	{"VSTPUE1015", "bigint"},
+	{"VSEcNoMeanTP0", "double precision"},
+	{"VSEcNoMeanTP1", "double precision"},
+	{"VSEcNoMeanTP2", "double precision"},
+	{"VSEcNoMeanTP3", "double precision"},
+	{"VSEcNoMeanTP4", "double precision"},
+	{"VSEcNoMeanTP5", "double precision"},
+	{"VSEcNoMeanTP69", "double precision"},
+	{"VSEcNoMeanTP1015", "double precision"},
+	{"VSEcNoMeanTP1625", "double precision"},
+	{"VSEcNoMeanTP2635", "double precision"},
+	{"VSEcNoMeanTP3655", "double precision"},
+	{"VSEcNoMeanTPMore55", "double precision"},
+	{"VSRRCRejSum", "bigint"},
+	{"VSRRCFailConnEstabCong", "bigint"},
+	{"VSRRCRejCodeCong", "bigint"},
+	{"VSRRCRejRLFail", "bigint"},
+	{"VSRRCRejTNLFail", "bigint"},
+	{"VSRRCRejRedirIntraRat", "bigint"},
+	{"VSRRCRejRedirInterRat", "bigint"},
+	{"VSRRCFailConnEstabNoReply", "bigint"},
+	{"VSRRCRejULCECong", "bigint"},
+	{"VSRRCRejDLCECong", "bigint"},
+	{"VSRRCRejULIUBBandCong", "bigint"},
+	{"VSRRCRejDLIUBBandCong", "bigint"},
+	{"VSRRCRejULPowerCong", "bigint"},
+	{"VSRRCRejDLPowerCong", "bigint"},
+	{"VSRRCRejRedirService", "bigint"},
+	{"VSLowPriRRCRanFCDiscNum", "bigint"},
+	{"VSNormPriRRCRanFCDiscNum", "bigint"},
+	{"VSHighPriRRCRanFCDiscNum", "bigint"},
+	{"VSRRCRejRedirDist", "bigint"},
+	{"VSRRCFCDiscNum", "bigint"},
+	{"VSRRCRejRedirDistIntraRat", "bigint"},
+	{"VSRRCRejNodeBResUnavail", "bigint"},
+	{"VSRRCRejRedirCoMacroMicro", "bigint"},
+	{"VSRRCRejRedirPingPongNum", "bigint"},
+	{"VSRRCRejNodeBULCECong", "bigint"},
+	{"VSRRCRejNodeBDLCECong", "bigint"},
+	{"VSRRCRejRedirWeakCoverage", "bigint"},
+	{"VSRRCFailConnEstabNoReplyCSFB", "bigint"},
+	{"VSRABAttEstabCSConv", "bigint"},
+	{"VSRABAttEstabCSStr", "bigint"},
+	{"VSRABSuccEstabCSConv", "bigint"},
+	{"VSRABSuccEstabCSStr", "bigint"},
+	{"VSRABAttEstabAMR", "bigint"},
+	{"VSRABAttEstCSConv64", "bigint"},
+	{"VSRABSuccEstabCSAMR", "bigint"},
+	{"VSRABSuccEstCSConv64", "bigint"},
+	{"VSRABSuccEstabCSAMR122", "bigint"},
+	{"VSRABAttEstabCSVPLimit", "bigint"},
+	{"VSRABAttEstabCSQueue", "bigint"},
+	{"VSRABEstabQueueTimeCS", "bigint"},
+	{"VSRABSuccEstabCSQueue", "bigint"},
+	{"VSRABFailEstabCSUnsp", "bigint"},
+	{"VSRABFailEstabCSCodeCong", "bigint"},
+	{"VSRABFailEstabCSCong", "bigint"},
+	{"VSRABFailEstabCSRNL", "bigint"},
+	{"VSRABFailEstabCSTNL", "bigint"},
+	{"VSRABFailEstabCSULCECong", "bigint"},
+	{"VSRABFailEstabCSDLCECong", "bigint"},
+	{"VSRABFailEstabCSDLIUBBandCong", "bigint"},
+	{"VSRABFailEstabCSULIUBBandCong", "bigint"},
+	{"VSRABFailEstabCSRBIncCfg", "bigint"},
+	{"VSRABFailEstabCSRBCfgUnsup", "bigint"},
+	{"VSRABFailEstabCSPhyChFail", "bigint"},
+	{"VSRABFailEstabCSUuNoReply", "bigint"},
+	{"VSRABFailEstabCSULPowerCong", "bigint"},
+	{"VSRABFailEstabCSDLPowerCong", "bigint"},
+	{"VSRABFailEstabCSIubFail", "bigint"},
+	{"VSRABFailEstabCSUuFail", "bigint"},
+	{"VSRABFailEstabCSSRBReset", "bigint"},
+	{"VSRABFailEstabCSCellUpd", "bigint"},
+	{"VSRABFailEstabCSNodeBULCECong", "bigint"},
+	{"VSRABFailEstabCSNodeBDLCECong", "bigint"},
+	{"VSRABFailEstabCSDLIUCSBandCong", "bigint"},
+	{"VSRABFailEstabCSULIUCSBandCong", "bigint"},
+	{"VSRABFailEstabCSIubAAL2Fail", "bigint"},
+	{"VSRABFailEstabCSSRBResetCSFB", "bigint"},
+	{"VSRABFailEstabCSCellUpdCSFB", "bigint"},
+	{"VSRABFailEstabCSUuFailCSFB", "bigint"},
+	{"VSRABAttRelCSNormRel", "bigint"},
+	{"VSRABAttRelCSUEInact", "bigint"},
+	{"VSRABAttRelCSPreempt", "bigint"},
+	{"VSRABAttRelCSOM", "bigint"},
+	{"VSRABAttRelCSNetOpt", "bigint"},
+	{"VSRABAttRelCSUTRANGen", "bigint"},
+	{"VSCNRABLossCS", "bigint"},
+	{"VSRABAttRelCS", "bigint"},
+	{"VSRABAttEstabPSConv", "bigint"},
+	{"VSRABAttEstabPSStr", "bigint"},
+	{"VSRABAttEstabPSInt", "bigint"},
+	{"VSRABAttEstabPSBkg", "bigint"},
+	{"VSRABSuccEstabPSConv", "bigint"},
+	{"VSRABSuccEstabPSStr", "bigint"},
+	{"VSRABSuccEstabPSInt", "bigint"},
+	{"VSRABSuccEstabPSBkg", "bigint"},
+	{"VSRABSuccEstabPS0kbps", "bigint"},
+	{"VSRABAttEstabPSQueue", "bigint"},
+	{"VSRABEstabQueueTimePS", "bigint"},
+	{"VSRABSuccEstabPSQueue", "bigint"},
+	{"VSRABSuccEstabPSPTT", "bigint"},
+	{"VSRABAttEstabPSPTT", "bigint"},
+	{"VSRABSuccEstabPSR99", "bigint"},
+	{"VSRABAttEstabPSR99", "bigint"},
+	{"VSRABAttEstabPSFree", "bigint"},
+	{"VSRABSuccEstabPSFree", "bigint"},
+	{"VSRABFailEstabPSUnsp", "bigint"},
+	{"VSRABFailEstabPSCodeCong", "bigint"},
+	{"VSRABFailEstabPSRNL", "bigint"},
+	{"VSRABFailEstabPSTNL", "bigint"},
+	{"VSRABFailEstabPSULCECong", "bigint"},
+	{"VSRABFailEstabPSDLCECong", "bigint"},
+	{"VSRABFailEstabPSDLIUBBandCong", "bigint"},
+	{"VSRABFailEstabPSULIUBBandCong", "bigint"},
+	{"VSRABFailEstabPSRBIncCfg", "bigint"},
+	{"VSRABFailEstabPSRBCfgUnsupp", "bigint"},
+	{"VSRABFailEstabPSPhyChFail", "bigint"},
+	{"VSRABFailEstabPSUuNoReply", "bigint"},
+	{"VSRABFailEstabPSULPowerCong", "bigint"},
+	{"VSRABFailEstabPSDLPowerCong", "bigint"},
+	{"VSRABFailEstabPSIubFail", "bigint"},
+	{"VSRABFailEstabPSUuFail", "bigint"},
+	{"VSRABFailEstabPSCong", "bigint"},
+	{"VSRABFailEstabPSDLPowerCongFree", "bigint"},
+	{"VSRABFailEstabPSSRBReset", "bigint"},
+	{"VSRABFailEstabPSCellUpd", "bigint"},
+	{"VSRABFailEstabPSHSUPAUserCong", "bigint"},
+	{"VSRABFailEstabPSHSDPAUserCong", "bigint"},
+	{"VSRABFailEstabPSNodeBULCECong", "bigint"},
+	{"VSRABFailEstabPSNodeBDLCECong", "bigint"},
+	{"VSRABFailEstabPSDLIUPSBandCong", "bigint"},
+	{"VSRABFailEstabPSULIUPSBandCong", "bigint"},
+	{"VSRABFailEstabPSIubAAL2Fail", "bigint"},
+	{"VSRABFailEstabPSULCEFinalCong", "bigint"},
+	{"VSRABAttRelPSNormRel", "bigint"},
+	{"VSRABAttRelPSUtranGen", "bigint"},
+	{"VSRABAttRelPSUeInact", "bigint"},
+	{"VSRABAttRelPSRABPreempt", "bigint"},
+	{"VSRABAttRelPSOM", "bigint"},
+	{"VSRABAttRelPSNetOptm", "bigint"},
+	{"VSRABAttRelPSUnsp", "bigint"},
+	{"VSCNRABLossPS", "bigint"},
+	{"VSRABAttRelPS", "bigint"},
+	{"VSRABAbnormRelCSRF", "bigint"},
+	{"VSRABAbnormRelCS", "bigint"},
+	{"VSRABNormRelCS", "bigint"},
+	{"VSRABAbnormRelPSRF", "bigint"},
+	{"VSRABAbnormRelPS", "bigint"},
+	{"VSRABNormRelPS", "bigint"},
+	{"VSRABAbnormRelCSOM", "bigint"},
+	{"VSRABAbnormRelCSUTRANgen", "bigint"},
+	{"VSRABAbnormRelCSPreempt", "bigint"},
+	{"VSRABAbnormRelPSOM", "bigint"},
+	{"VSRABAbnormRelPSPreempt", "bigint"},
+	{"VSRABAbnormRelCSRFSRBReset", "bigint"},
+	{"VSRABAbnormRelPSRFSRBReset", "bigint"},
+	{"VSRABAbnormRelPSRFTRBReset", "bigint"},
+	{"VSRABAbnormRelCSIuAAL2", "bigint"},
+	{"VSRABAbnormRelPSGTPULoss", "bigint"},
+	{"VSRABAbnormRelAMR", "bigint"},
+	{"VSRABAbnormRelCS64", "bigint"},
+	{"VSRABAbnormRelCSRFULSync", "bigint"},
+	{"VSRABAbnormRelPSRFULSync", "bigint"},
+	{"VSRABAbnormRelCSRFUuNoReply", "bigint"},
+	{"VSRABAbnormRelPSRFUuNoReply", "bigint"},
+	{"VSRABNormRelAMR", "bigint"},
+	{"VSRABAbnormRelPSOLC", "bigint"},
+	{"VSRABAbnormRelCSOLC", "bigint"},
+	{"VSRABNormRelCS64", "bigint"},
+	{"VSRABNormRelPSCCH", "bigint"},
+	{"VSRABAbnormRelPSCCH", "bigint"},
+	{"VSRABNormRelPSUEGen", "bigint"},
+	{"VSRABAbnormRelCSStr", "bigint"},
+	{"VSRABAbnormRelPSConv", "bigint"},
+	{"VSRABAbnormRelPSStr", "bigint"},
+	{"VSRABNormRelCSStr", "bigint"},
+	{"VSRABNormRelPSConv", "bigint"},
+	{"VSRABNormRelPSStr", "bigint"},
+	{"VSRABRelReqPSBEHSUPACongGolden", "bigint"},
+	{"VSRABRelReqPSBEHSUPACongSilver", "bigint"},
+	{"VSRABAbnormRelCSHSPAConv", "bigint"},
+	{"VSRABNormRelCSHSPAConv", "bigint"},
+	{"VSRABNormRelVPLimit", "bigint"},
+	{"VSRABNormRelPS0kbpsTimeout", "bigint"},
+	{"VSRABNormRelCSUEGen", "bigint"},
+	{"VSRABNormRelPSBE", "bigint"},
+	{"VSRABAbnormRelPSBE", "bigint"},
+	{"VSRABAbnormRelCS64RF", "bigint"},
+	{"VSRABAbnormRelPSPTT", "bigint"},
+	{"VSRABNormRelPSPTT", "bigint"},
+	{"VSRABAbnormRelPSR99RF", "bigint"},
+	{"VSRABAbnormRelPSR99", "bigint"},
+	{"VSRABNormRelPSR99", "bigint"},
+	{"VSRABNormRelPSPCH", "bigint"},
+	{"VSRABAbnormRelPSPCH", "bigint"},
+	{"VSRABAbnormRelPSF2P", "bigint"},
+	{"VSRABAbnormRelPSD2P", "bigint"},
+	{"VSRABAbnormRelPSR99D2P", "bigint"},
+	{"VSRABSFOccupyMAX", "double precision"},
+	{"VSMultRABSF8", "bigint"},
+	{"VSMultRABSF16", "bigint"},
+	{"VSMultRABSF32", "bigint"},
+	{"VSMultRABSF64", "bigint"},
+	{"VSSingleRABSF4", "bigint"},
+	{"VSSingleRABSF8", "bigint"},
+	{"VSSingleRABSF16", "bigint"},
+	{"VSSingleRABSF32", "bigint"},
+	{"VSSingleRABSF64", "bigint"},
+	{"VSSingleRABSF128", "bigint"},
+	{"VSSingleRABSF256", "bigint"},
+	{"VSMultRABSF4", "bigint"},
+	{"VSMultRABSF128", "bigint"},
+	{"VSMultRABSF256", "bigint"},
+	{"VSRABSFOccupy", "bigint"},
+	{"VSDRDRBSetupAttOut", "bigint"},
+	{"VSDRDRBSetupSuccOut", "bigint"},
+	{"VSDRDRBSetupAttIn", "bigint"},
+	{"VSDRDRBSetupSuccIn", "bigint"},
+	{"VSRBCSConvDL64", "bigint"},
+	{"VSRBPSIntDL8", "bigint"},
+	{"VSRBPSIntDL16", "bigint"},
+	{"VSRBPSIntDL32", "bigint"},
+	{"VSRBPSIntDL64", "bigint"},
+	{"VSRBPSIntDL128", "bigint"},
+	{"VSRBPSIntDL144", "bigint"},
+	{"VSRBPSIntDL256", "bigint"},
+	{"VSRBPSIntDL384", "bigint"},
+	{"VSRBPSIntUL8", "bigint"},
+	{"VSRBPSIntUL16", "bigint"},
+	{"VSRBPSIntUL32", "bigint"},
+	{"VSRBPSIntUL64", "bigint"},
+	{"VSRBPSIntUL128", "bigint"},
+	{"VSRBPSIntUL144", "bigint"},
+	{"VSRBPSIntUL256", "bigint"},
+	{"VSRBPSIntUL384", "bigint"},
+	{"VSRBPSBkgDL8", "bigint"},
+	{"VSRBPSBkgDL16", "bigint"},
+	{"VSRBPSBkgDL32", "bigint"},
+	{"VSRBPSBkgDL64", "bigint"},
+	{"VSRBPSBkgDL128", "bigint"},
+	{"VSRBPSBkgDL144", "bigint"},
+	{"VSRBPSBkgDL256", "bigint"},
+	{"VSRBPSBkgDL384", "bigint"},
+	{"VSRBPSBkgUL8", "bigint"},
+	{"VSRBPSBkgUL16", "bigint"},
+	{"VSRBPSBkgUL32", "bigint"},
+	{"VSRBPSBkgUL64", "bigint"},
+	{"VSRBPSBkgUL128", "bigint"},
+	{"VSRBPSBkgUL144", "bigint"},
+	{"VSRBPSBkgUL256", "bigint"},
+	{"VSRBPSBkgUL384", "bigint"},
+	{"VSRBAMRDL122", "bigint"},
+	{"VSSHOAttRLAdd", "bigint"},
+	{"VSSHOSuccRLAdd", "bigint"},
+	{"VSSHOFailRLAddCfgUnsupp", "bigint"},
+	{"VSSHOFailRLAddISR", "bigint"},
+	{"VSSHOFailRLAddInvCfg", "bigint"},
+	{"VSSHOFailRLAddNoReply", "bigint"},
+	{"VSSHOAttRLDel", "bigint"},
+	{"VSSHOSuccRLDel", "bigint"},
+	{"VSSHOAS1RL", "bigint"},
+	{"VSSHOAS2RL", "bigint"},
+	{"VSSHOAS3RL", "bigint"},
+	{"VSSHOAS4RL", "bigint"},
+	{"VSSHOAS5RL", "bigint"},
+	{"VSSHOAS6RL", "bigint"},
+	{"VSHHOAttInterFreqOut", "bigint"},
+	{"VSHHOSuccInterFreqOut", "bigint"},
+	{"VSHHOFailInterFreqOutCfgUnsupp", "bigint"},
+	{"VSHHOFailInterFreqOutPyhChFail", "bigint"},
+	{"VSHHOFailInterFreqOutISR", "bigint"},
+	{"VSHHOFailInterFreqOutCellUpdt", "bigint"},
+	{"VSHHOFailInterFreqOutInvCfg", "bigint"},
+	{"VSHHOFailInterFreqOutNoReply", "bigint"},
+	{"VSHHOFailInterFreqOutPrepFail", "bigint"},
+	{"VSHHOFailInterFreqOutRLSetupFail", "bigint"},
+	{"IRATHOAttRelocPrepOutCS", "bigint"},
+	{"IRATHOSuccRelocPrepOutCS", "bigint"},
+	{"IRATHOAttOutCS", "bigint"},
+	{"IRATHOSuccOutCS", "bigint"},
+	{"IRATHOFailOutCSCfgUnsupp", "bigint"},
+	{"IRATHOFailOutCSPhyChFail", "bigint"},
+	{"IRATHOAttOutPSUTRAN", "bigint"},
+	{"IRATHOSuccOutPSUTRAN", "bigint"},
+	{"IRATHOFailOutPSUTRANCfgUnsupp", "bigint"},
+	{"IRATHOFailOutPSUTRANPhyChFail", "bigint"},
+	{"VSIRATHOFailOutCSNoReply", "bigint"},
+	{"VSIRATHOFailOutPSUTRANNoReply", "bigint"},
+	{"VSIRATHOAttOutCSTrigRscp", "bigint"},
+	{"VSIRATHOAttOutCSTrigEcNo", "bigint"},
+	{"VSIRATHOAttOutPSTrigRscp", "bigint"},
+	{"VSIRATHOAttOutPSTrigEcNo", "bigint"},
+	{"VSIRATHOSuccOutCSTrigRscp", "bigint"},
+	{"VSIRATHOSuccOutCSTrigEcNo", "bigint"},
+	{"VSIRATHOSuccOutPSTrigRscp", "bigint"},
+	{"VSIRATHOSuccOutPSTrigEcNo", "bigint"},
+	{"VSIRATHOFailOutCSAbort", "bigint"},
+	{"VSIRATHOFailOutPSAbort", "bigint"},
+	{"VSMeanRTWP", "double precision"},
+	{"VSMeanTCP", "double precision"},
+	{"VSMaxRTWP", "double precision"},
+	{"VSMinRTWP", "double precision"},
+	{"VSMaxTCP", "double precision"},
+	{"VSMinTCP", "double precision"},
+	{"VSMaxTCPNonHS", "double precision"},
+	{"VSMinTCPNonHS", "double precision"},
+	{"VSMeanTCPNonHS", "double precision"},
+	{"VSIUBAttRLSetup", "bigint"},
+	{"VSIUBAttRLAdd", "bigint"},
+	{"VSIUBAttRLRecfg", "bigint"},
+	{"VSHSDPAD2HSucc", "bigint"},
+	{"VSHSDPAF2HSucc", "bigint"},
+	{"VSHSDPAH2DSucc", "bigint"},
+	{"VSHSDPAH2FSucc", "bigint"},
+	{"VSHSDPAMeanChThroughputTotalBytes", "double precision"},
+	{"VSHSDPASHOServCellChgAttOut", "bigint"},
+	{"VSHSDPASHOServCellChgSuccOut", "bigint"},
+	{"VSHSDPARABAttEstab", "bigint"},
+	{"VSHSDPARABSuccEstab", "bigint"},
+	{"VSHSDPAHHOH2DSuccOutIntraFreq", "bigint"},
+	{"VSHSDPAHHOH2DSuccOutInterFreq", "bigint"},
+	{"VSHSDPARABNormRelUsrInact", "bigint"},
+	{"VSHSDPARABAbnormRel", "bigint"},
+	{"VSHSDPARABAbnormRelRF", "bigint"},
+	{"VSHSDPARABNormRel", "bigint"},
+	{"VSHSDPAMeanChThroughput", "double precision"},
+	{"VSHSDPAUEMeanCell", "double precision"},
+	{"VSHSDPARABFailEstabDLPowerCong", "bigint"},
+	{"VSHSDPARABFailEstabDLIUBBandCong", "bigint"},
+	{"VSHSDPAUEMaxCell", "double precision"},
+	{"VSHSDPARABDCAttEstab", "bigint"},
+	{"VSHSDPARABDCSuccEstab", "bigint"},
+	{"VSHSDPA64QAMUEMeanCell", "double precision"},
+	{"VSHSDPADCPRIMUEMeanCell", "double precision"},
+	{"VSHSDPADCSECUEMeanCell", "double precision"},
+	{"VSHSDPARABAbnormRelH2P", "bigint"},
+	{"VSLCULCreditUsedMax", "double precision"},
+	{"VSLCULCreditUsedMin", "double precision"},
+	{"VSLCDLCreditUsedMax", "double precision"},
+	{"VSLCDLCreditUsedMin", "double precision"},
+	{"VSDCCCSuccF2P", "bigint"},
+	{"VSCellUnavailTime", "bigint"},
+	{"VSLCULCreditUsedMean", "double precision"},
+	{"VSLCDLCreditUsedMean", "double precision"},
+	{"VSCellUnavailTimeSys", "bigint"},
+	{"VSDCCCD2PSucc", "bigint"},
+	{"VSHSDPAH2PSucc", "bigint"},
+	{"VSHSUPAE2PSucc", "bigint"},
+	{"VSPSR99D2PSucc", "bigint"},
+	{"VSHSUPARABAttEstab", "bigint"},
+	{"VSHSUPARABSuccEstab", "bigint"},
+	{"VSHSUPARABAbnormRel", "bigint"},
+	{"VSHSUPARABNormRel", "bigint"},
+	{"VSHSUPAE2DSucc", "bigint"},
+	{"VSHSUPAHHOE2DSuccOutIntraFreq", "bigint"},
+	{"VSHSUPAHHOE2DSuccOutInterFreq", "bigint"},
+	{"VSHSUPAE2FSucc", "bigint"},
+	{"VSHSUPAMeanChThroughputTotalBytes", "double precision"},
+	{"VSHSUPAUEMeanCell", "double precision"},
+	{"VSHSUPAMeanChThroughput", "double precision"},
+	{"VSHSUPARABFailEstabULPowerCong", "bigint"},
+	{"VSHSUPARABFailEstabULIUBBandCong", "bigint"},
+	{"VSHSUPARABFailEstabULCECong", "bigint"},
+	{"VSHSUPAUEMaxCell", "double precision"},
+	{"VSHSUPARABAbnormRelE2P", "bigint"},
+	{"VSHSUPADCPRIMUEMeanCell", "double precision"},
+	{"VSHSUPAUEMaxTTI2ms", "double precision"},
+	{"VSHSUPAUEMaxTTI10ms", "double precision"},
+	{"VSHSUPADCSECUEMeanCell", "double precision"},
+	{"VSHSUPAUEMeanTTI2ms", "double precision"},
+	{"VSHSUPAUEMeanTTI10ms", "double precision"},
+	{"VSPSBkgDL8Traffic", "double precision"},
+	{"VSPSBkgDL16Traffic", "double precision"},
+	{"VSPSBkgDL32Traffic", "double precision"},
+	{"VSPSBkgDL64Traffic", "double precision"},
+	{"VSPSBkgDL128Traffic", "double precision"},
+	{"VSPSBkgDL144Traffic", "double precision"},
+	{"VSPSBkgDL256Traffic", "double precision"},
+	{"VSPSBkgDL384Traffic", "double precision"},
+	{"VSPSIntDL8Traffic", "double precision"},
+	{"VSPSIntDL16Traffic", "double precision"},
+	{"VSPSIntDL32Traffic", "double precision"},
+	{"VSPSIntDL64Traffic", "double precision"},
+	{"VSPSIntDL128Traffic", "double precision"},
+	{"VSPSIntDL144Traffic", "double precision"},
+	{"VSPSIntDL256Traffic", "double precision"},
+	{"VSPSIntDL384Traffic", "double precision"},
+	{"VSPSStrDL32Traffic", "double precision"},
+	{"VSPSStrDL64Traffic", "double precision"},
+	{"VSPSStrDL128Traffic", "double precision"},
+	{"VSPSStrDL144Traffic", "double precision"},
+	{"VSPSBkgUL8Traffic", "double precision"},
+	{"VSPSBkgUL16Traffic", "double precision"},
+	{"VSPSBkgUL32Traffic", "double precision"},
+	{"VSPSBkgUL64Traffic", "double precision"},
+	{"VSPSBkgUL128Traffic", "double precision"},
+	{"VSPSBkgUL144Traffic", "double precision"},
+	{"VSPSBkgUL256Traffic", "double precision"},
+	{"VSPSBkgUL384Traffic", "double precision"},
+	{"VSPSIntUL8Traffic", "double precision"},
+	{"VSPSIntUL16Traffic", "double precision"},
+	{"VSPSIntUL32Traffic", "double precision"},
+	{"VSPSIntUL64Traffic", "double precision"},
+	{"VSPSIntUL128Traffic", "double precision"},
+	{"VSPSIntUL144Traffic", "double precision"},
+	{"VSPSIntUL256Traffic", "double precision"},
+	{"VSPSIntUL384Traffic", "double precision"},
+	{"VSPSStrUL16Traffic", "double precision"},
+	{"VSPSStrUL32Traffic", "double precision"},
+	{"VSPSStrUL64Traffic", "double precision"},
+	{"VSPSBkgKbpsDL8", "double precision"},
+	{"VSPSBkgKbpsDL16", "double precision"},
+	{"VSPSBkgKbpsDL32", "double precision"},
+	{"VSPSBkgKbpsDL64", "double precision"},
+	{"VSPSBkgKbpsDL128", "double precision"},
+	{"VSPSBkgKbpsDL144", "double precision"},
+	{"VSPSBkgKbpsDL256", "double precision"},
+	{"VSPSBkgKbpsDL384", "double precision"},
+	{"VSPSIntKbpsDL8", "double precision"},
+	{"VSPSIntKbpsDL16", "double precision"},
+	{"VSPSIntKbpsDL32", "double precision"},
+	{"VSPSIntKbpsDL64", "double precision"},
+	{"VSPSIntKbpsDL128", "double precision"},
+	{"VSPSIntKbpsDL144", "double precision"},
+	{"VSPSIntKbpsDL256", "double precision"},
+	{"VSPSIntKbpsDL384", "double precision"},
+	{"VSPSStrKbpsDL32", "double precision"},
+	{"VSPSStrKbpsDL64", "double precision"},
+	{"VSPSStrKbpsDL128", "double precision"},
+	{"VSPSStrKbpsDL144", "double precision"},
+	{"VSPSBkgKbpsUL8", "double precision"},
+	{"VSPSBkgKbpsUL16", "double precision"},
+	{"VSPSBkgKbpsUL32", "double precision"},
+	{"VSPSBkgKbpsUL64", "double precision"},
+	{"VSPSBkgKbpsUL128", "double precision"},
+	{"VSPSBkgKbpsUL144", "double precision"},
+	{"VSPSBkgKbpsUL256", "double precision"},
+	{"VSPSBkgKbpsUL384", "double precision"},
+	{"VSPSIntKbpsUL8", "double precision"},
+	{"VSPSIntKbpsUL16", "double precision"},
+	{"VSPSIntKbpsUL32", "double precision"},
+	{"VSPSIntKbpsUL64", "double precision"},
+	{"VSPSIntKbpsUL128", "double precision"},
+	{"VSPSIntKbpsUL144", "double precision"},
+	{"VSPSIntKbpsUL256", "double precision"},
+	{"VSPSIntKbpsUL384", "double precision"},
+	{"VSPSStrKbpsUL16", "double precision"},
+	{"VSPSStrKbpsUL32", "double precision"},
+	{"VSPSStrKbpsUL64", "double precision"},
+	{"VSRRCPaging1LossPCHCongCell", "bigint"},
+	{"VSUTRANAttPaging1", "bigint"},
+	{"VSCellDCHUEs", "bigint"},
+	{"VSCellFACHUEs", "bigint"},
+	{"VSCellPCHUEs", "bigint"},
+	{"VSDCCCSuccF2U", "bigint"},
+	{"VSDCCCSuccD2U", "bigint"},
+	{"VSIRATHOHSDPAAttOutPSUTRAN", "bigint"},
+	{"VSIRATHOHSDPASuccOutPSUTRAN", "bigint"},
+	{"VSIRATHOHSUPASuccOutPSUTRAN", "bigint"},
+	{"VSIRATHOHSUPAAttOutPSUTRAN", "bigint"},
+	{"VSCellFACHUEsMAX", "double precision"},
+	{"VSFACHDTCHCONGTIME", "bigint"},
+	{"VSFACHDCCHCONGTIME", "bigint"},
+	{"VSFACHCCCHCONGTIME", "bigint"},
+	{"VSDCCCP2DAtt", "bigint"},
+	{"VSDCCCP2DDRDAtt", "bigint"},
+	{"VSOrigCallEstabMeanTimeAMRNB", "double precision"},
+	{"VSOrigCallEstabMeanTimeAMRWB", "double precision"},
+	{"VSAMRErlangBestCell", "double precision"},
+	{"VSRBAMRWBDL1265", "bigint"},
+	{"VSRABAttEstabCSAMRWB", "bigint"},
+	{"VSRABSuccEstabCSAMRWB", "bigint"},
+	{"VSRABAbnormRelAMRWB", "bigint"},
+	{"VSRABNormRelAMRWB", "bigint"},
+	{"VSRRCFCNumFACHCong", "bigint"},
+	{"VSSuccCellUpdtOrgConvCallPCH", "bigint"},
+	{"VSSuccCellUpdtTmConvCallPCH", "bigint"},
+	{"VSSuccCellUpdtEmgCallPCH", "bigint"},
+	{"VSAttCellUpdtOrgConvCallPCH", "bigint"},
+	{"VSAttCellUpdtTmConvCallPCH", "bigint"},
+	{"VSVPErlangBestCell", "double precision"},
+	{"VSPSBEkbitsUL032BestCell", "double precision"},
+	{"VSPSBEkbitsUL3264BestCell", "double precision"},
+	{"VSPSBEkbitsUL64144BestCell", "double precision"},
+	{"VSPSBEkbitsUL144384BestCell", "double precision"},
+	{"VSSuccEstabPSAfterP2F", "bigint"},
+	{"VSAttEstabPSAfterP2F", "bigint"},
+	{"VSSuccEstabPSAfterP2D", "bigint"},
+	{"VSAttEstabPSAfterP2D", "bigint"},
+	{"VSSuccRecfgF2HDataTransTrig", "bigint"},
+	{"VSSuccRecfgP2HDataTransTrig", "bigint"},
+	{"VSAttRecfgF2HDataTransTrig", "bigint"},
+	{"VSAttRecfgP2HDataTransTrig", "bigint"},
+	{"VSSuccRecfgF2EDataTransTrig", "bigint"},
+	{"VSSuccRecfgP2EDataTransTrig", "bigint"},
+	{"VSAttRecfgF2EDataTransTrig", "bigint"},
+	{"VSAttRecfgP2EDataTransTrig", "bigint"},
+	{"VSSRNCIubBytesPSR99StrRx", "bigint"},
+	{"VSSRNCIubBytesPSR99IntRx", "bigint"},
+	{"VSSRNCIubBytesPSR99BkgRx", "bigint"},
+	{"VSSRNCIubBytesPSR99ConvRx", "bigint"},
+	{"VSCRNCIubBytesPSR99CCHRx", "bigint"},
+	{"VSSRNCIubBytesPSR99StrTx", "bigint"},
+	{"VSSRNCIubBytesPSR99IntTx", "bigint"},
+	{"VSSRNCIubBytesPSR99BkgTx", "bigint"},
+	{"VSSRNCIubBytesPSR99ConvTx", "bigint"},
+	{"VSCRNCIubBytesPSR99CCHTx", "bigint"},
+	{"VSSRNCIubBytesHSDPATx", "bigint"},
+	{"VSSRNCIubBytesPSEFACHTx", "bigint"},
+	{"VSSRNCIubBytesHSUPARx", "bigint"},
+	{"VSAttCellUpdtEmgCallPCH", "bigint"},
+	{"VSRABAbnormRelAMR795", "bigint"},
+	{"VSRABAbnormRelAMR122", "bigint"},
+	{"VSRABAbnormRelAMR59", "bigint"},
+	{"VSRABAbnormRelAMR475", "bigint"},
+	{"VSRABAbnormRelAMRRF", "bigint"},
+	{"VSRABAbnormRelCSOthers", "bigint"},
+	{"VSRABAbnormRelCSIuTNL", "bigint"},
+	{"VSRABAbnormRelCSIuupFail", "bigint"},
+	{"VSRABAbnormRelCSCN", "bigint"},
+	{"VSRABAbnormRelCSCSFB", "bigint"},
+	{"VSRABAbnormRelCSCSFBRF", "bigint"},
+	{"VSRABAbnormRelCSPlatinum", "bigint"},
+	{"VSRABAbnormRelCSSecurity", "bigint"},
+	{"VSRABAbnormRelPSOthers", "bigint"},
+	{"VSRABAbnormRelPSUTRANgen", "bigint"},
+	{"VSRABAbnormRelPSIuTNL", "bigint"},
+	{"VSRABAbnormRelPSRFOthers", "bigint"},
+	{"VSRABAbnormRelPSCN", "bigint"},
+	{"VSRABAbnormRelPSSecurity", "bigint"},
+	{"VSRABAbnormRelPSR99D2F", "bigint"},
+	{"VSRABAbnormRelPSR99CellDCHCellUpdt", "bigint"},
+	{"VSHSDPARABAbnormRel64QAM", "bigint"},
+	{"VSHSDPARABAbnormRel64QAM2P", "bigint"},
+	{"VSHSDPARABAbnormRelCellDCHCellUpdt", "bigint"},
+	{"VSHSDPARABAbnormRelDC", "bigint"},
+	{"VSHSDPARABAbnormRelDC2P", "bigint"},
+	{"VSHSDPARABAbnormRelDCMIMO2P", "bigint"},
+	{"VSHSDPARABAbnormRelH2F", "bigint"},
+	{"VSHSDPARABAbnormRelSRBoH", "bigint"},
+	{"VSHSDPARABAbnormRelSRBoHH2P", "bigint"},
+	{"VSHHOInterFreqOutCSDrop", "bigint"},
+	{"VSHHOIntraFreqOutDrop", "bigint"},
+	{"VSHHOInterFreqOutPSDrop", "bigint"},
+	{"VSRRCRejRedirIntraRatCSService", "bigint"},
+	{"VSRRCRejRedirIntraRatPSService", "bigint"},
+	{"VSRRCRejRedirInterRatCSService", "bigint"},
+	{"VSRRCRejRedirInterRatPSService", "bigint"},
+	{"VSSHOFailRLAddIubHW", "bigint"},
+	{"VSIRATHOFailOutCSCNUnspecFail", "bigint"},
+	{"VSIRATHOFailOutCSInterRatRF", "bigint"},
+	{"VSIRATHOFailOutCSSCRI", "bigint"},
+	{"VSIRATHOFailOutPSUTRANCNUnspecFail", "bigint"},
+	{"VSIRATHOFailOutPSUTRANInterRatRF", "bigint"},
+	{"VSIRATHOFailOutPSUTRANNoSRNSDataForwardCmd", "bigint"},
+	{"VSIRATHOFailOutPSUTRANSCRI", "bigint"},
+	{"VSIRATHOFailOutPS", "bigint"},
+	{"VSIRATHOFailOutPSUEGen", "bigint"},
+	{"VSHHOFailInterFreqOutInterRNCCellUpdt", "bigint"},
+	{"VSHHOFailInterFreqOutInterRNCCfgUnsupp", "bigint"},
+	{"VSHHOFailInterFreqOutInterRNCInvCfg", "bigint"},
+	{"VSHHOFailInterFreqOutInterRNCISR", "bigint"},
+	{"VSHHOFailInterFreqOutInterRNCNoReply", "bigint"},
+	{"VSHHOFailInterFreqOutInterRNCPhyChFail", "bigint"},
+	{"VSRRCPaging1PCHCongCSPreemptAtt", "bigint"},
+	{"VSIUBFailRLRecfgCong", "bigint"},
+	{"VSHSUPARABAbnormRelRF", "bigint"},
+	{"VSHSDPAD2HAtt", "bigint"},
+	{"VSHSUPAD2ESucc", "bigint"},
+	{"VSHSUPAD2EAtt", "bigint"},
+	{"VSPSBEkbitsDL032BestCell", "double precision"},
+	{"VSPSBEkbitsDL3264BestCell", "double precision"},
+	{"VSPSBEkbitsDL64144BestCell", "double precision"},
+	{"VSPSBEkbitsDL144384BestCell", "double precision"},
+	{"VSHSUPAGoldenBeMeanChThroughputTotalBytes", "double precision"},
+	{"VSHSUPASilverBeMeanChThroughputTotalBytes", "double precision"},
+	{"VSHSUPACopperBeMeanChThroughputTotalBytes", "double precision"},
+	{"VSRRCSuccConnEstabCSFB", "bigint"},
+	{"VSRRCAttConnEstabCSFB", "bigint"},
+	{"VSRABSuccEstabCSCSFBRedir", "bigint"},
+	{"VSRABAttEstabCSCSFBRedir", "bigint"},
+}
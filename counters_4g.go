@@ -0,0 +1,65 @@
+package main
+
+// huawei4GKeys are the leading columns of the 4G tables, in COPY order. The
+// export's network element is the eNodeB and cellid its local cell id.
+var huawei4GKeys = []column{
+	{"resulttime", "timestamp"},
+	{"unique_id", "text"},
+	{"enodeb", "text"},
+	{"cellname", "text"},
+	{"cellid", "text"},
+}
+
+// huawei4GCounters is the built-in core set of eNodeB cell counters, named
+// like the 3G catalogue (L.RRC.ConnReq.Att becomes LRRCConnReqAtt). Load the
+// full export with --catalogue.
+var huawei4GCounters = []column{
+	{"LRRCConnReqAtt", "bigint"},
+	{"LRRCConnReqSucc", "bigint"},
+	{"LS1SigConnEstAtt", "bigint"},
+	{"LS1SigConnEstSucc", "bigint"},
+	{"LERABAttEst", "bigint"},
+	{"LERABSuccEst", "bigint"},
+	{"LERABAbnormRel", "bigint"},
+	{"LERABNormRel", "bigint"},
+	{"LCSFBPrepAtt", "bigint"},
+	{"LCSFBPrepSucc", "bigint"},
+	{"LHHOIntraeNBIntraFreqExecAttOut", "bigint"},
+	{"LHHOIntraeNBIntraFreqExecSuccOut", "bigint"},
+	{"LHHOIntereNBIntraFreqExecAttOut", "bigint"},
+	{"LHHOIntereNBIntraFreqExecSuccOut", "bigint"},
+	{"LThrpbitsDL", "bigint"},
+	{"LThrpbitsUL", "bigint"},
+	{"LThrpTimeDL", "bigint"},
+	{"LThrpTimeUL", "bigint"},
+	{"LChMeasPRBDLUsedAvg", "double precision"},
+	{"LChMeasPRBULUsedAvg", "double precision"},
+	{"LChMeasPRBDLAvail", "bigint"},
+	{"LChMeasPRBULAvail", "bigint"},
+	{"LTrafficUserAvg", "double precision"},
+	{"LTrafficUserMax", "bigint"},
+	{"LCellUnavailDurSys", "bigint"},
+}
+
+var huawei4GKPIs = []kpi{
+	{"rrc_sr", "100 * LRRCConnReqSucc / LRRCConnReqAtt"},
+	{"s1_sr", "100 * LS1SigConnEstSucc / LS1SigConnEstAtt"},
+	{"erab_sr", "100 * LERABSuccEst / LERABAttEst"},
+	{"cssr", "100 * LRRCConnReqSucc / LRRCConnReqAtt * LS1SigConnEstSucc / LS1SigConnEstAtt * LERABSuccEst / LERABAttEst"},
+	{"erab_drop_rate", "100 * LERABAbnormRel / (LERABAbnormRel + LERABNormRel)"},
+	{"csfb_sr", "100 * LCSFBPrepSucc / LCSFBPrepAtt"},
+	{"intra_freq_ho_sr", "100 * (LHHOIntraeNBIntraFreqExecSuccOut + LHHOIntereNBIntraFreqExecSuccOut) / (LHHOIntraeNBIntraFreqExecAttOut + LHHOIntereNBIntraFreqExecAttOut)"},
+	{"dl_throughput_mbps", "LThrpbitsDL / LThrpTimeDL / 1000"},
+	{"ul_throughput_mbps", "LThrpbitsUL / LThrpTimeUL / 1000"},
+	{"prb_dl_util", "100 * LChMeasPRBDLUsedAvg / LChMeasPRBDLAvail"},
+	{"availability", "100 * (period_seconds - LCellUnavailDurSys) / period_seconds"},
+}
+
+var huawei4GSuccessAttemptPairs = [][2]string{
+	{"LRRCConnReqSucc", "LRRCConnReqAtt"},
+	{"LS1SigConnEstSucc", "LS1SigConnEstAtt"},
+	{"LERABSuccEst", "LERABAttEst"},
+	{"LCSFBPrepSucc", "LCSFBPrepAtt"},
+	{"LHHOIntraeNBIntraFreqExecSuccOut", "LHHOIntraeNBIntraFreqExecAttOut"},
+	{"LHHOIntereNBIntraFreqExecSuccOut", "LHHOIntereNBIntraFreqExecAttOut"},
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"log"
+	"strings"
+)
+
+// Values of --dedup-policy
+const (
+	dedupFirst = "first"
+	dedupLast  = "last"
+	dedupError = "error"
+)
+
+// dedupCount is the number of duplicate rows dropped.
+var dedupCount int64
+
+// dedupRow is a row in the dedup window. Under the first and error policies
+// only its key and line number are kept.
+type dedupRow struct {
+	key     string
+	line    string
+	lineNo  int64
+	dropped bool
+}
+
+// deduper drops rows whose --dedup-key columns repeat those of a row among the
+// last --dedup-window rows. Memory is bounded by the window: a duplicate further
+// apart than that is not detected. Under the last policy rows are held back
+// until they leave the window, since a later duplicate replaces them.
+type deduper struct {
+	keys   []int // indexes of the key columns in a transformed row
+	policy string
+	seen   map[string]int64 // key to the sequence number of its latest row
+	window []dedupRow       // ring indexed by sequence number
+	seq    int64            // sequence number of the next row
+	out    []dedupRow
+}
+
+// dedup is nil when --dedup-key is not set.
+var dedup *deduper
+
+// newDeduper resolves --dedup-key against the destination table's columns, in
+// the order COPY receives them.
+func newDeduper() *deduper {
+	if dedupKey == "" {
+		return nil
+	}
+	switch dedupPolicy {
+	case dedupFirst, dedupLast, dedupError:
+	default:
+		log.Fatalf("Invalid --dedup-policy %q, expected %s, %s or %s", dedupPolicy, dedupFirst, dedupLast, dedupError)
+	}
+	if dedupWindow <= 0 {
+		log.Fatal("--dedup-window must be positive")
+	}
+
+	index := make(map[string]int)
+	for i, c := range loadTableColumns() {
+		index[strings.ToLower(c.Name)] = i
+	}
+	d := &deduper{policy: dedupPolicy, seen: make(map[string]int64), window: make([]dedupRow, dedupWindow)}
+	for _, name := range strings.Split(dedupKey, ",") {
+		i, ok := index[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			log.Fatalf("--dedup-key column %q is not a column of %s", name, getFullTableName())
+		}
+		d.keys = append(d.keys, i)
+	}
+	return d
+}
+
+// key joins the key columns of a line. Lines that cannot be split get no key
+// and pass through, to be rejected by validation.
+func (d *deduper) key(line, sep string) (string, bool) {
+	fields, err := profile.transform(line, sep)
+	if err != nil {
+		return "", false
+	}
+	parts := make([]string, len(d.keys))
+	for i, k := range d.keys {
+		if k >= len(fields) {
+			return "", false
+		}
+		parts[i] = fields[k]
+	}
+	return strings.Join(parts, "\x00"), true
+}
+
+// push adds a line and returns the rows that are ready to be loaded, in input
+// order.
+func (d *deduper) push(line, sep string, lineNo int64) []dedupRow {
+	d.out = d.out[:0]
+	key, ok := d.key(line, sep)
+	if !ok {
+		return append(d.out, dedupRow{line: line, lineNo: lineNo})
+	}
+
+	if prev, dup := d.seen[key]; dup {
+		earlier := &d.window[prev%int64(len(d.window))]
+		switch d.policy {
+		case dedupError:
+			log.Fatalf("Line %d duplicates line %d on --dedup-key %s", lineNo, earlier.lineNo, dedupKey)
+		case dedupFirst:
+			dedupCount++
+			return d.out
+		case dedupLast:
+			earlier.dropped = true
+			dedupCount++
+		}
+	}
+
+	// Make room by evicting the oldest row of a full window
+	slot := &d.window[d.seq%int64(len(d.window))]
+	if d.seq >= int64(len(d.window)) {
+		if d.seen[slot.key] == d.seq-int64(len(d.window)) {
+			delete(d.seen, slot.key)
+		}
+		if d.policy == dedupLast && !slot.dropped {
+			d.out = append(d.out, *slot)
+		}
+	}
+
+	*slot = dedupRow{key: key, lineNo: lineNo}
+	d.seen[key] = d.seq
+	d.seq++
+	if d.policy == dedupLast {
+		slot.line = line
+	} else {
+		d.out = append(d.out, dedupRow{line: line, lineNo: lineNo})
+	}
+	return d.out
+}
+
+// flush returns the rows still held back at the end of the input.
+func (d *deduper) flush() []dedupRow {
+	d.out = d.out[:0]
+	if d.policy != dedupLast {
+		return d.out
+	}
+	start := d.seq - int64(len(d.window))
+	if start < 0 {
+		start = 0
+	}
+	for s := start; s < d.seq; s++ {
+		if row := d.window[s%int64(len(d.window))]; !row.dropped {
+			d.out = append(d.out, row)
+		}
+	}
+	return d.out
+}
+
+// firstHeld returns the line number of the earliest row held back, or 0.
+func (d *deduper) firstHeld() int64 {
+	if d.policy != dedupLast {
+		return 0
+	}
+	start := d.seq - int64(len(d.window))
+	if start < 0 {
+		start = 0
+	}
+	for s := start; s < d.seq; s++ {
+		if row := d.window[s%int64(len(d.window))]; !row.dropped {
+			return row.lineNo
+		}
+	}
+	return 0
+}
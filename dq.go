@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// successAttemptPairs are the 3G counters where the success count can never
+// exceed the attempt count.
+var successAttemptPairs = [][2]string{
+	{"RRCSuccConnEstabsum", "VSRRCAttConnEstabSum"},
+	{"RRCSuccConnEstabOrgConvCall", "RRCAttConnEstabOrgConvCall"},
+	{"RRCSuccConnEstabTmConvCall", "RRCAttConnEstabTmConvCall"},
+	{"RRCSuccConnEstabEmgCall", "RRCAttConnEstabEmgCall"},
+	{"VSRABSuccEstabCSConv", "VSRABAttEstabCSConv"},
+	{"VSRABSuccEstabCSStr", "VSRABAttEstabCSStr"},
+	{"VSRABSuccEstabPSConv", "VSRABAttEstabPSConv"},
+	{"VSRABSuccEstabPSStr", "VSRABAttEstabPSStr"},
+	{"VSRABSuccEstabPSInt", "VSRABAttEstabPSInt"},
+	{"VSRABSuccEstabPSBkg", "VSRABAttEstabPSBkg"},
+	{"VSHSDPARABSuccEstab", "VSHSDPARABAttEstab"},
+	{"VSHSUPARABSuccEstab", "VSHSUPARABAttEstab"},
+	{"VSSHOSuccRLAdd", "VSSHOAttRLAdd"},
+	{"VSSHOSuccRLDel", "VSSHOAttRLDel"},
+	{"VSHHOSuccInterFreqOut", "VSHHOAttInterFreqOut"},
+	{"IRATHOSuccOutCS", "IRATHOAttOutCS"},
+	{"IRATHOSuccOutPSUTRAN", "IRATHOAttOutPSUTRAN"},
+}
+
+// dqTableDDL creates the table every data-quality stage writes its findings to.
+func dqTableDDL(table string) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id bigserial PRIMARY KEY,
+	run_id text NOT NULL,
+	check_name text NOT NULL,
+	resulttime timestamp,
+	unique_id text,
+	value numeric,
+	detail text
+);`, table)
+}
+
+// successPairValues builds the VALUES rows unpivoting the success/attempt
+// pairs of the --profile technology for a staging row aliased t. Pairs not in
+// the catalogue are skipped.
+func successPairValues(counters []column) string {
+	known := make(map[string]bool, len(counters))
+	for _, c := range counters {
+		known[strings.ToLower(c.Name)] = true
+	}
+
+	var rows []string
+	for _, p := range tech.successAttemptPairs {
+		if known[strings.ToLower(p[0])] && known[strings.ToLower(p[1])] {
+			rows = append(rows, fmt.Sprintf("('%[1]s', '%[2]s', t.%[1]s, t.%[2]s)", p[0], p[1]))
+		}
+	}
+	if len(rows) == 0 {
+		// Keep the statement valid when the catalogue has none of the pairs
+		return "(NULL, NULL, NULL::numeric, NULL::numeric)"
+	}
+	return strings.Join(rows, ", ")
+}
+
+// counterValues builds the VALUES rows unpivoting the numeric counters of a
+// staging row aliased t into (name, value) pairs.
+func counterValues(counters []column) string {
+	var rows []string
+	for _, c := range counters {
+		if isNumericType(c.Type) {
+			rows = append(rows, fmt.Sprintf("('%[1]s', t.%[1]s::numeric)", c.Name))
+		}
+	}
+	if len(rows) == 0 {
+		return "(NULL, NULL::numeric)"
+	}
+	return strings.Join(rows, ", ")
+}
+
+func isDQStage(s stage) bool {
+	return strings.HasPrefix(s.name, "dq-")
+}
+
+// reportFindings prints the number of findings per check for this run.
+func reportFindings(db *sqlx.DB) {
+	var findings []struct {
+		Check string `db:"check_name"`
+		Count int64  `db:"n"`
+	}
+	err := db.Select(&findings, fmt.Sprintf("SELECT check_name, count(*) AS n FROM %s WHERE run_id = $1 GROUP BY check_name ORDER BY check_name",
+		quoteTable(schemaName, dqTable)), runID)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(findings) == 0 {
+		fmt.Println("[DQ] no findings")
+		return
+	}
+	for _, f := range findings {
+		fmt.Printf("[DQ] %s: %d finding(s)\n", f.Check, f.Count)
+	}
+	fmt.Printf("[DQ] details in %s where run_id = '%s'\n", quoteTable(schemaName, dqTable), runID)
+}
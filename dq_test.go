@@ -0,0 +1,14 @@
+package main
+
+import "testing"
+
+func TestCounterValuesOnlyNumeric(t *testing.T) {
+	counters := []column{{"cellname", "text"}, {"c1", "numeric"}, {"c2", "bigint"}, {"c3", "double precision"}}
+	want := "('c1', t.c1::numeric), ('c2', t.c2::numeric), ('c3', t.c3::numeric)"
+	if got := counterValues(counters); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if got := counterValues([]column{{"cellname", "text"}}); got != "(NULL, NULL::numeric)" {
+		t.Errorf("without numeric counters got %s", got)
+	}
+}
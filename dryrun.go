@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxReportedErrors limits how many invalid rows a dry run prints.
+const maxReportedErrors = 10
+
+// dryRun parses and validates the input against the destination table and
+// prints the statements a real run would execute, without writing anything.
+func dryRun(scanner lineSource, stages []stage) {
+	cols := loadTableColumns()
+	timeIdx := -1
+	for i, c := range cols {
+		if strings.EqualFold(c.Name, timeColumn) {
+			timeIdx = i
+		}
+	}
+
+	batchChan := make(chan *batch, workers)
+	done := make(chan struct{})
+
+	var valid, invalid int64
+	var from, to time.Time
+	go func() {
+		defer close(done)
+		sChar := splitSeparator()
+		for b := range batchChan {
+			for i, line := range b.rows {
+				fields, err := profile.transform(line, sChar)
+				if err == nil {
+					err = validateRow(fields, cols)
+				}
+				if err != nil {
+					invalid++
+					if invalid <= maxReportedErrors {
+						fmt.Printf("[INVALID] line %d: %s\n", b.lineNo(i), err.Error())
+					}
+					continue
+				}
+				valid++
+
+				if timeIdx < 0 || isNull(fields[timeIdx]) {
+					continue
+				}
+				if t, err := parseTimestamp(fields[timeIdx]); err == nil {
+					if from.IsZero() || t.Before(from) {
+						from = t
+					}
+					if t.After(to) {
+						to = t
+					}
+				}
+			}
+		}
+	}()
+
+	start := time.Now()
+	rowsRead := scan(batchSize, scanner, [][]chan *batch{{batchChan}})
+	close(batchChan)
+	<-done
+
+	fmt.Println("COPY statement:")
+	fmt.Println("  " + copyCommand())
+
+	if !noPostLoad && current != nil && !from.IsZero() {
+		params := newStageParams(from, to, dryRunCounters())
+		for _, s := range stages {
+			fmt.Printf("Post-load stage %s:\n  %s\n", s.name, renderStage(s, params))
+		}
+	}
+
+	fmt.Printf("DRY RUN %d rows read, %d valid, %d invalid, %d outside --from/--to, %d duplicates, %d columns, took %v\n", rowsRead, valid, invalid, filteredCount, dedupCount, len(cols), time.Now().Sub(start))
+	if !from.IsZero() {
+		fmt.Printf("Time range %s to %s\n", from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+}
+
+// dryRunCounters returns the rollup counters, including migrated ones unless
+// working offline from --schema-file.
+func dryRunCounters() []column {
+	if len(schemaFile) > 0 {
+		return rollupCounters(nil)
+	}
+	db := connect()
+	return rollupCounters(db)
+}
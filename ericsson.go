@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ericssonCounterMap maps Ericsson RNC counters to the catalogue counters used
+// by the rollup, KPIs and data-quality checks.
+var ericssonCounterMap = map[string]string{
+	"pmTotNoRrcConnectReq":                     "VSRRCAttConnEstabSum",
+	"pmTotNoRrcConnectReqSuccess":              "RRCSuccConnEstabsum",
+	"pmNoRabEstablishAttemptSpeech":            "VSRABAttEstabCSConv",
+	"pmNoRabEstablishSuccessSpeech":            "VSRABSuccEstabCSConv",
+	"pmNoRabEstablishAttemptPacketInteractive": "VSRABAttEstabPSInt",
+	"pmNoRabEstablishSuccessPacketInteractive": "VSRABSuccEstabPSInt",
+	"pmNoSystemRabReleaseSpeech":               "VSRABAbnormRelCS",
+	"pmNoNormalRabReleaseSpeech":               "VSRABNormRelCS",
+	"pmNoSystemRabReleasePacket":               "VSRABAbnormRelPS",
+	"pmNoNormalRabReleasePacket":               "VSRABNormRelPS",
+	"pmNoAttOutIratHoSpeech":                   "IRATHOAttOutCS",
+	"pmNoSuccessOutIratHoSpeech":               "IRATHOSuccOutCS",
+}
+
+// measCollecFile is a 3GPP TS 32.435 measurement file. Both the older
+// measTypes/measResults lists and the indexed measType/r elements are read.
+type measCollecFile struct {
+	FileHeader struct {
+		FileSender struct {
+			LocalDn string `xml:"localDn,attr"`
+		} `xml:"fileSender"`
+	} `xml:"fileHeader"`
+	MeasData []struct {
+		ManagedElement struct {
+			LocalDn   string `xml:"localDn,attr"`
+			UserLabel string `xml:"userLabel,attr"`
+		} `xml:"managedElement"`
+		MeasInfo []struct {
+			GranPeriod struct {
+				Duration string `xml:"duration,attr"`
+				EndTime  string `xml:"endTime,attr"`
+			} `xml:"granPeriod"`
+			MeasTypes string `xml:"measTypes"`
+			MeasType  []struct {
+				P    string `xml:"p,attr"`
+				Name string `xml:",chardata"`
+			} `xml:"measType"`
+			MeasValue []struct {
+				MeasObjLdn  string `xml:"measObjLdn,attr"`
+				MeasResults string `xml:"measResults"`
+				R           []struct {
+					P     string `xml:"p,attr"`
+					Value string `xml:",chardata"`
+				} `xml:"r"`
+			} `xml:"measValue"`
+		} `xml:"measInfo"`
+	} `xml:"measData"`
+}
+
+// parseISODuration reads the PT<n>H<n>M<n>S durations of granPeriod.
+func parseISODuration(s string) (time.Duration, error) {
+	if !strings.HasPrefix(s, "PT") {
+		return 0, fmt.Errorf("unsupported duration %q", s)
+	}
+	return time.ParseDuration(strings.ToLower(s[2:]))
+}
+
+// parseEricsson reads an Ericsson 3GPP XML export. The cell comes from the
+// moid of each measValue: RNC is the MeContext, taken from the moid, the
+// managed element or the file sender, in that order (else the element label),
+// the cell name the UtranCell, and UNIQUE_ID is rnc/cellname. The moid
+// carries no CI, so the UtranCell id stands in for it and the cell dimension
+// still tells the cells of an RNC apart. Objects other than UtranCell are
+// skipped. resulttime is the start of the granularity period.
+func parseEricsson(r io.Reader, mapping *counterMapping) (*pmRows, error) {
+	var doc measCollecFile
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	rows := newPMRows(mapping)
+	for _, md := range doc.MeasData {
+		elementRNC := cellFromPairs(md.ManagedElement.LocalDn, ",", "=")["mecontext"]
+		if elementRNC == "" {
+			elementRNC = cellFromPairs(doc.FileHeader.FileSender.LocalDn, ",", "=")["mecontext"]
+		}
+		if elementRNC == "" {
+			elementRNC = md.ManagedElement.UserLabel
+		}
+
+		for _, mi := range md.MeasInfo {
+			end, err := time.Parse(time.RFC3339, mi.GranPeriod.EndTime)
+			if err != nil {
+				return nil, fmt.Errorf("granPeriod endTime: %s", err.Error())
+			}
+			period, err := parseISODuration(mi.GranPeriod.Duration)
+			if err != nil {
+				return nil, err
+			}
+			t := end.Add(-period)
+
+			types := strings.Fields(mi.MeasTypes)
+			byIndex := make(map[string]string, len(mi.MeasType))
+			for _, mt := range mi.MeasType {
+				byIndex[mt.P] = strings.TrimSpace(mt.Name)
+			}
+
+			for _, mv := range mi.MeasValue {
+				moid := cellFromPairs(mv.MeasObjLdn, ",", "=")
+				cellname := moid["utrancell"]
+				if cellname == "" {
+					continue
+				}
+				rnc := moid["mecontext"]
+				if rnc == "" {
+					rnc = elementRNC
+				}
+				cell := pmCell{uniqueID: rnc + "/" + cellname, rnc: rnc, cellname: cellname, ci: cellname}
+
+				for i, v := range strings.Fields(mv.MeasResults) {
+					if i < len(types) {
+						rows.set(t, cell, types[i], v)
+					}
+				}
+				for _, res := range mv.R {
+					name, ok := byIndex[res.P]
+					if !ok {
+						return nil, fmt.Errorf("measValue %s: r p=%s has no measType", mv.MeasObjLdn, res.P)
+					}
+					rows.set(t, cell, name, res.Value)
+				}
+			}
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// migrationTable records every column --evolve-schema adds.
+const migrationTable = "schema_migrations"
+
+// readHeader consumes the header line of the input and returns its column
+// names in COPY order, i.e. with unique_id inserted after the timestamp.
+func readHeader(scanner lineSource) []string {
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			log.Fatalf("Error reading header: %s", err.Error())
+		}
+		log.Fatal("--header is set but the input is empty")
+	}
+	fields, err := transformLine(scanner.Text(), splitSeparator())
+	if err != nil {
+		log.Fatalf("Invalid header: %s", err.Error())
+	}
+	fields[1] = "unique_id"
+	for i := range fields {
+		fields[i] = counterIdent(fields[i])
+	}
+	return fields
+}
+
+// counterIdent turns a header name such as "VS.RRC.AttConnEstab.Sum" into the
+// column name used by the catalogue, "vsrrcattconnestabsum".
+func counterIdent(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return -1
+	}, name)
+}
+
+func existingColumns(db *sqlx.DB, table string) map[string]bool {
+	var names []string
+	err := db.Select(&names, `SELECT lower(column_name) FROM information_schema.columns
+		WHERE table_schema = $1 AND table_name = $2`, schemaName, table)
+	if err != nil {
+		panic(err)
+	}
+	existing := make(map[string]bool, len(names))
+	for _, n := range names {
+		existing[n] = true
+	}
+	return existing
+}
+
+func ensureMigrationTable(db *sqlx.DB) {
+	db.MustExec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id serial PRIMARY KEY,
+	applied_at timestamptz NOT NULL DEFAULT now(),
+	table_name text NOT NULL,
+	column_name text NOT NULL,
+	data_type text NOT NULL,
+	ddl text NOT NULL
+)`, quoteTable(schemaName, migrationTable)))
+}
+
+// migratedCounters returns the counters earlier runs added with
+// --evolve-schema, so the rollup keeps summing them. It returns nil when no
+// migration has ever been applied.
+func migratedCounters(db *sqlx.DB) []column {
+	var cols []column
+	err := db.Select(&cols, fmt.Sprintf(`SELECT column_name, min(data_type) AS data_type FROM %s
+		WHERE table_name = $1 GROUP BY column_name ORDER BY min(id)`, quoteTable(schemaName, migrationTable)), hourlyTable)
+	if err != nil {
+		return nil
+	}
+	return cols
+}
+
+// rollupCounters is the catalogue plus the migrated counters. db may be nil
+// when working offline.
+func rollupCounters(db *sqlx.DB) []column {
+	counters := loadCounters()
+	if db == nil {
+		return counters
+	}
+	known := make(map[string]bool, len(counters))
+	for _, c := range counters {
+		known[strings.ToLower(c.Name)] = true
+	}
+	for _, c := range migratedCounters(db) {
+		if !known[strings.ToLower(c.Name)] {
+			counters = append(counters, c)
+		}
+	}
+	return counters
+}
+
+// evolveSchema adds the header columns missing from the staging table to the
+// staging, hourly and daily tables, logging each ALTER to schema_migrations.
+// With --dry-run the ALTERs are only printed.
+func evolveSchema(header []string) {
+	db := connect()
+
+	existing := existingColumns(db, tableName)
+	var added []string
+	for _, name := range header {
+		if !existing[name] {
+			added = append(added, name)
+		}
+	}
+	if len(added) == 0 {
+		return
+	}
+
+	tables := []string{tableName, hourlyTable, dailyTable}
+	if dailyMode == dailyModeContinuous {
+		// A continuous aggregate cannot be altered, only recreated
+		tables = tables[:2]
+		fmt.Printf("%s is a continuous aggregate; drop it and run init-schema to add the new counters to it\n", quoteTable(schemaName, dailyTable))
+	}
+
+	if dryRunMode {
+		for _, table := range tables {
+			for _, name := range added {
+				fmt.Printf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s;\n", quoteTable(schemaName, table), name, evolveType)
+			}
+		}
+		return
+	}
+
+	ensureMigrationTable(db)
+	start := time.Now()
+	tx := db.MustBegin()
+	for _, table := range tables {
+		for _, name := range added {
+			ddl := fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s", quoteTable(schemaName, table), name, evolveType)
+			tx.MustExec(ddl)
+			tx.MustExec(fmt.Sprintf("INSERT INTO %s (table_name, column_name, data_type, ddl) VALUES ($1, $2, $3, $4)",
+				quoteTable(schemaName, migrationTable)), table, name, evolveType, ddl)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+	fmt.Printf("Added %d new counter(s) in %v: %s\n", len(added), time.Now().Sub(start), strings.Join(added, ", "))
+}
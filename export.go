@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// exportSource returns the table --level selects and its time column.
+func exportSource() (table, timeCol string) {
+	switch exportLevel {
+	case "hourly":
+		return hourlyTable, timeColumn
+	case "daily":
+		return dailyTable, "tanggal"
+	}
+	log.Fatalf("Invalid --level %q, expected hourly or daily", exportLevel)
+	return "", ""
+}
+
+// exportCells returns the --cell-list names; a leading @ reads them from a
+// file, one per line.
+func exportCells() []string {
+	list := cellList
+	if strings.HasPrefix(list, "@") {
+		data, err := ioutil.ReadFile(list[1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		list = strings.Replace(string(data), "\n", ",", -1)
+	}
+	var cells []string
+	for _, c := range strings.Split(list, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			cells = append(cells, c)
+		}
+	}
+	return cells
+}
+
+// exportWhere builds the --rnc and --cell-list conditions and the [from, to)
+// range; zero times are left open.
+func exportWhere(timeCol string, from, to time.Time) (string, []interface{}) {
+	var where []string
+	var args []interface{}
+	if len(rncFilter) > 0 {
+		args = append(args, pq.Array(strings.Split(rncFilter, ",")))
+		where = append(where, fmt.Sprintf("%s = ANY($%d)", tech.ne(), len(args)))
+	}
+	if cells := exportCells(); len(cells) > 0 {
+		args = append(args, pq.Array(cells))
+		where = append(where, fmt.Sprintf("(cellname = ANY($%[1]d) OR unique_id = ANY($%[1]d))", len(args)))
+	}
+	if !from.IsZero() {
+		args = append(args, from.Format(pgTimestampLayout))
+		where = append(where, fmt.Sprintf("%s >= $%d", timeCol, len(args)))
+	}
+	if !to.IsZero() {
+		args = append(args, to.Format(pgTimestampLayout))
+		where = append(where, fmt.Sprintf("%s < $%d", timeCol, len(args)))
+	}
+	if len(where) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(where, " AND "), args
+}
+
+// exportQuery selects the rows of table in [from, to) matching the filters.
+func exportQuery(table, timeCol string, from, to time.Time) (string, []interface{}) {
+	where, args := exportWhere(timeCol, from, to)
+	return fmt.Sprintf("SELECT * FROM %s%s ORDER BY %s", quoteTable(schemaName, table), where, timeCol), args
+}
+
+// splitRange cuts [from, to) into at most n consecutive ranges aligned to unit.
+func splitRange(from, to time.Time, n int, unit time.Duration) [][2]time.Time {
+	step := to.Sub(from) / time.Duration(n)
+	step = (step + unit - 1) / unit * unit
+	if step < unit {
+		step = unit
+	}
+	var ranges [][2]time.Time
+	for t := from; t.Before(to); t = t.Add(step) {
+		end := t.Add(step)
+		if end.After(to) {
+			end = to
+		}
+		ranges = append(ranges, [2]time.Time{t, end})
+	}
+	return ranges
+}
+
+// exportPart is the output of one export worker, spooled to a temporary file
+// so the parts can be joined in time order.
+type exportPart struct {
+	file    *os.File
+	columns []string
+	rows    int64
+}
+
+// exportRange writes the rows of one time range to a temporary file.
+func exportRange(table, timeCol string, from, to time.Time) *exportPart {
+	file, err := ioutil.TempFile("", "export-*.csv")
+	if err != nil {
+		log.Fatal(err)
+	}
+	os.Remove(file.Name()) // keep it only as long as it is open
+
+	conn, ctx, release := phaseConn(phaseExport)
+	defer release()
+	query, args := exportQuery(table, timeCol, from, to)
+	rows, err := conn.QueryxContext(ctx, query, args...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer rows.Close()
+
+	part := &exportPart{file: file}
+	if part.columns, err = rows.Columns(); err != nil {
+		log.Fatal(err)
+	}
+
+	buf := bufio.NewWriter(file)
+	w := csv.NewWriter(buf)
+	w.Comma = []rune(splitSeparator())[0]
+	record := make([]string, len(part.columns))
+	for rows.Next() {
+		values, err := rows.SliceScan()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for i, v := range values {
+			record[i] = exportValue(v)
+		}
+		if err := w.Write(record); err != nil {
+			log.Fatal(err)
+		}
+		part.rows++
+	}
+	if err := rows.Err(); err != nil {
+		log.Fatal(err)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.Fatal(err)
+	}
+	if err := buf.Flush(); err != nil {
+		log.Fatal(err)
+	}
+	return part
+}
+
+// exportValue formats a scanned value; NULL is written as an empty field.
+func exportValue(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return x.Format(pgTimestampLayout)
+	case []byte:
+		return string(x)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// exportCSV writes the hourly or daily rows matching --rnc, --cell-list and
+// --from/--to to --out. The time range is split across --workers, each
+// querying its own slice; the parts are then joined in time order.
+func exportCSV() {
+	if compressOutput != "" && compressOutput != "gzip" {
+		log.Fatalf("Invalid --compress %q, only gzip is supported", compressOutput)
+	}
+	table, timeCol := exportSource()
+	parseTimeFilters()
+
+	healthCheck(quoteTable(schemaName, table), "SELECT")
+	db := connect()
+
+	start := time.Now()
+	from, to := fromTime, toTime
+	if from.IsZero() || to.IsZero() {
+		var r struct {
+			From *time.Time `db:"from_time"`
+			To   *time.Time `db:"to_time"`
+		}
+		where, args := exportWhere(timeCol, from, to)
+		err := db.Get(&r, fmt.Sprintf("SELECT min(%[1]s) AS from_time, max(%[1]s) AS to_time FROM %[2]s%[3]s", timeCol, quoteTable(schemaName, table), where), args...)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if r.From == nil {
+			fmt.Fprintln(os.Stderr, "Nothing to export")
+			return
+		}
+		if from.IsZero() {
+			from = *r.From
+		}
+		if to.IsZero() {
+			to = r.To.Add(time.Microsecond)
+		}
+	}
+
+	unit := time.Hour
+	if exportLevel == "daily" {
+		unit = 24 * time.Hour
+	}
+	ranges := splitRange(from, to, workers, unit)
+	parts := make([]*exportPart, len(ranges))
+	var wg sync.WaitGroup
+	for i, r := range ranges {
+		wg.Add(1)
+		go func(i int, from, to time.Time) {
+			defer wg.Done()
+			parts[i] = exportRange(table, timeCol, from, to)
+		}(i, r[0], r[1])
+	}
+	wg.Wait()
+
+	var out io.Writer = os.Stdout
+	if len(outFile) > 0 {
+		file, err := os.Create(outFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		out = file
+	}
+	buf := bufio.NewWriter(out)
+	out = buf
+	var gz *gzip.Writer
+	if compressOutput == "gzip" {
+		gz = gzip.NewWriter(buf)
+		out = gz
+	}
+
+	if hasHeader {
+		fmt.Fprintln(out, strings.Join(parts[0].columns, splitSeparator()))
+	}
+	var total int64
+	for _, p := range parts {
+		if _, err := p.file.Seek(0, io.SeekStart); err != nil {
+			log.Fatal(err)
+		}
+		if _, err := io.Copy(out, p.file); err != nil {
+			log.Fatal(err)
+		}
+		p.file.Close()
+		total += p.rows
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := buf.Flush(); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported %d rows from %s in %v with %d worker(s)\n", total, quoteTable(schemaName, table), time.Now().Sub(start), workers)
+}
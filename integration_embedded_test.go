@@ -0,0 +1,36 @@
+//go:build integration && !pgbouncer
+
+package main
+
+// With -tags integration the tests run against a PostgreSQL server that
+// embedded-postgres downloads and starts, so neither a local installation nor
+// docker is needed. TimescaleDB is not part of that server: the tables are
+// created without hypertables and time_bucket is defined in plain SQL.
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+)
+
+const testPort = 54329
+
+var (
+	testConnect     = fmt.Sprintf("host=localhost port=%d user=postgres password=postgres sslmode=disable", testPort)
+	testDB          = "pm_test"
+	testArgs        []string
+	testTimescaleDB = false
+)
+
+// startServer starts the embedded server with its files in dir.
+func startServer(dir string) (stop func()) {
+	pg := embeddedpostgres.NewDatabase(embeddedpostgres.DefaultConfig().
+		Port(testPort).
+		Database(testDB).
+		RuntimePath(filepath.Join(dir, "pg")).
+		Logger(ioutil.Discard))
+	check(pg.Start())
+	return func() { pg.Stop() }
+}
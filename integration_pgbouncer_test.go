@@ -0,0 +1,207 @@
+//go:build pgbouncer
+
+package main
+
+// With -tags pgbouncer the tests load through PgBouncer in transaction pooling
+// mode, with --pgbouncer and a statement timeout for every phase, into the
+// TimescaleDB of docker-compose.pgbouncer.yml:
+//
+//	docker compose -f docker-compose.pgbouncer.yml up -d
+//	go test -tags pgbouncer .
+//
+// PGBOUNCER_TEST_CONNECT overrides the connection string of PgBouncer. The
+// tests below check in process what --pgbouncer changes about the
+// connections.
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// pgbouncerPoolSize is DEFAULT_POOL_SIZE of docker-compose.pgbouncer.yml, the
+// number of server connections PgBouncer keeps to the database.
+const pgbouncerPoolSize = 4
+
+var (
+	testConnect     = pgbouncerConnect()
+	testDB          = "db_demo"
+	testArgs        = []string{"--pgbouncer", "--statement-timeout", "setup=1m,copy=1m,post-load=10m"}
+	testTimescaleDB = true
+)
+
+func pgbouncerConnect() string {
+	if c := os.Getenv("PGBOUNCER_TEST_CONNECT"); c != "" {
+		return c
+	}
+	return "host=localhost port=6432 user=demo password=demo sslmode=disable"
+}
+
+// startServer has nothing to start, the servers run in docker compose.
+func startServer(dir string) (stop func()) {
+	return func() {}
+}
+
+// pgbouncerTarget sets the flags of a --pgbouncer run with the given
+// --statement-timeout and returns the pool of its target.
+func pgbouncerTarget(t *testing.T, timeouts string) *sqlx.DB {
+	t.Helper()
+	setFlag(t, "connection", testConnect)
+	setFlag(t, "db-name", testDB)
+	setFlag(t, "pgbouncer", "true")
+	setFlag(t, "statement-timeout", timeouts)
+	oldTimeouts, oldTargets, oldCurrent := statementTimeouts, targets, current
+	parseStatementTimeouts()
+	setupTargets()
+	t.Cleanup(func() {
+		closeTargets()
+		statementTimeouts, targets, current = oldTimeouts, oldTargets, oldCurrent
+	})
+	db := connect()
+	db.SetMaxOpenConns(4 * pgbouncerPoolSize)
+	return db
+}
+
+// serverTimeouts returns statement_timeout of every server connection behind
+// PgBouncer. Each transaction holds a server connection of its own until it
+// ends, so pgbouncerPoolSize concurrent ones see all of them.
+func serverTimeouts(t *testing.T, db *sqlx.DB) []string {
+	t.Helper()
+	ctx := context.Background()
+	var txs []*sqlx.Tx
+	defer func() {
+		for _, tx := range txs {
+			tx.Rollback()
+		}
+	}()
+	var settings []string
+	for i := 0; i < pgbouncerPoolSize; i++ {
+		tx, err := db.BeginTxx(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		txs = append(txs, tx)
+		var v string
+		if err := tx.GetContext(ctx, &v, "SHOW statement_timeout"); err != nil {
+			t.Fatal(err)
+		}
+		settings = append(settings, v)
+	}
+	return settings
+}
+
+func checkNoServerTimeout(t *testing.T, db *sqlx.DB) {
+	t.Helper()
+	for i, v := range serverTimeouts(t, db) {
+		if v != "0" {
+			t.Errorf("server connection %d kept statement_timeout %s", i, v)
+		}
+	}
+}
+
+// TestPgBouncerBinaryParameters runs queries with parameters from more clients
+// than PgBouncer has server connections. Without binary_parameters lib/pq
+// parses and executes them in two round trips, between which PgBouncer may
+// hand the server connection to another client.
+func TestPgBouncerBinaryParameters(t *testing.T) {
+	db := pgbouncerTarget(t, "")
+	if !strings.Contains(current.connect, "binary_parameters=yes") {
+		t.Fatalf("connection string %q has no binary_parameters=yes", current.connect)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 4*pgbouncerPoolSize)
+	for c := 0; c < 4*pgbouncerPoolSize; c++ {
+		wg.Add(1)
+		go func(c int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				var sum int
+				if err := db.Get(&sum, "SELECT $1::int + $2::int", c, i); err != nil {
+					errs <- err
+					return
+				}
+				if sum != c+i {
+					errs <- fmt.Errorf("$1 + $2 = %d, want %d", sum, c+i)
+					return
+				}
+			}
+		}(c)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// TestPgBouncerNoSessionSet checks that statements outside a transaction get
+// their timeout as a context deadline rather than a session SET, which would
+// stay on the server connection.
+func TestPgBouncerNoSessionSet(t *testing.T) {
+	db := pgbouncerTarget(t, "post-load=10m")
+
+	conn, ctx, release := phaseConn(phasePostLoad)
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > 10*time.Minute {
+		t.Errorf("post-load context has no 10m deadline")
+	}
+	var v string
+	if err := conn.GetContext(ctx, &v, "SHOW statement_timeout"); err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if v != "0" {
+		t.Errorf("statement_timeout %s on the post-load connection, want no SET", v)
+	}
+	checkNoServerTimeout(t, db)
+}
+
+// TestPgBouncerSetLocal checks that COPY transactions set their timeout with
+// SET LOCAL, which ends with the transaction.
+func TestPgBouncerSetLocal(t *testing.T) {
+	db := pgbouncerTarget(t, "copy=5m")
+
+	ctx := context.Background()
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := setLocalTimeout(ctx, tx, phaseCopy); err != nil {
+		t.Fatal(err)
+	}
+	var v string
+	if err := tx.GetContext(ctx, &v, "SHOW statement_timeout"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if v != "5min" {
+		t.Errorf("statement_timeout %s in the COPY transaction, want 5min", v)
+	}
+	checkNoServerTimeout(t, db)
+}
+
+// TestPgBouncerLoadLeavesNoSettings truncates, loads and runs the post-load
+// stages with timeouts for every phase, then checks no server connection kept
+// one.
+func TestPgBouncerLoadLeavesNoSettings(t *testing.T) {
+	resetTables(t)
+	out, err := runImport(t, "--file", testdataPath(t, "counters_3g.csv"), "--workers", "2", "--batch-size", "2",
+		"--reject-file", os.DevNull, "--truncate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "[STAGE] daily ") {
+		t.Errorf("post-load stages did not run:\n%s", out)
+	}
+	checkGolden(t, "daily.golden", dumpTable(t, testDaily))
+
+	checkNoServerTimeout(t, pgbouncerTarget(t, ""))
+}
@@ -0,0 +1,313 @@
+//go:build integration || pgbouncer
+
+package main
+
+// The integration tests build the importer and run it against a PostgreSQL
+// server. Which server depends on the build tag:
+//
+//	go test -tags integration .   // embedded-postgres, integration_embedded_test.go
+//	go test -tags pgbouncer .     // PgBouncer, integration_pgbouncer_test.go
+//
+// The server file sets testConnect, testDB and testArgs and provides
+// startServer. The tables are compared with the golden files in testdata;
+// -update rewrites them.
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/parquet-go/parquet-go"
+)
+
+var update = flag.Bool("update", false, "Rewrite the golden files of the integration tests")
+
+const (
+	testStaging = "counter_3g_lastday"
+	testHourly  = "counter_3g_hourly"
+	testDaily   = "counter_3g_daily"
+)
+
+var (
+	testBinary string
+	testConn   *sqlx.DB
+)
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	os.Exit(runIntegration(m))
+}
+
+// runIntegration builds the importer and starts the server. It panics rather
+// than exits on errors, so the server is stopped.
+func runIntegration(m *testing.M) int {
+	dir, err := ioutil.TempDir("", "3g-data-import")
+	check(err)
+	defer os.RemoveAll(dir)
+
+	testBinary = filepath.Join(dir, "3g-data-import")
+	if out, err := exec.Command("go", "build", "-o", testBinary, ".").CombinedOutput(); err != nil {
+		panic(fmt.Sprintf("go build: %s\n%s", err, out))
+	}
+
+	stop := startServer(dir)
+	defer stop()
+
+	testConn = sqlx.MustConnect("postgres", testConnect+" dbname="+testDB)
+	defer testConn.Close()
+	createTestSchema()
+	return m.Run()
+}
+
+// createTestSchema creates the tables of init-schema. Without TimescaleDB the
+// TimescaleDB statements are left out, and time_bucket is defined for the
+// fixed-length buckets of the daily stage.
+func createTestSchema() {
+	out, err := exec.Command(testBinary, "init-schema", "--dry-run", "--catalogue", "testdata/catalogue.csv", "--table", testStaging).Output()
+	check(err)
+	for _, stmt := range strings.Split(string(out), ";\n") {
+		if strings.TrimSpace(stmt) == "" {
+			continue
+		}
+		if !testTimescaleDB && (strings.Contains(stmt, "timescaledb") || strings.Contains(stmt, "create_hypertable")) {
+			continue
+		}
+		testConn.MustExec(stmt)
+	}
+	if testTimescaleDB {
+		return
+	}
+	testConn.MustExec(`CREATE OR REPLACE FUNCTION time_bucket(bucket interval, ts timestamp) RETURNS timestamp
+		LANGUAGE sql IMMUTABLE AS $$
+		SELECT to_timestamp(floor(extract(epoch FROM ts) / extract(epoch FROM bucket)) * extract(epoch FROM bucket)) AT TIME ZONE 'UTC'
+	$$`)
+}
+
+func testdataPath(t *testing.T, name string) string {
+	t.Helper()
+	path, err := filepath.Abs(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// runImport runs the importer on the test tables and returns its output. It
+// runs in a directory of its own, which gets its logs.txt.
+func runImport(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	args = append([]string{"--connection", testConnect, "--db-name", testDB, "--table", testStaging,
+		"--catalogue", testdataPath(t, "catalogue.csv")}, append(testArgs, args...)...)
+	cmd := exec.Command(testBinary, args...)
+	cmd.Dir = t.TempDir()
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		err = fmt.Errorf("%s: %s", err, stderr.String())
+	}
+	return string(out), err
+}
+
+func resetTables(t *testing.T) {
+	t.Helper()
+	testConn.MustExec(fmt.Sprintf("TRUNCATE %s, %s, %s", quoteTable("public", testStaging),
+		quoteTable("public", testHourly), quoteTable("public", testDaily)))
+}
+
+// dumpTable renders a table as CSV with a header, ordered by its key. NULL is
+// written as \N.
+func dumpTable(t *testing.T, table string) string {
+	t.Helper()
+	rows, err := testConn.Query(fmt.Sprintf("SELECT * FROM %s ORDER BY 1, 2", quoteTable("public", table)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	names, err := rows.Columns()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf strings.Builder
+	buf.WriteString(strings.Join(names, ",") + "\n")
+	values := make([]interface{}, len(names))
+	dest := make([]interface{}, len(names))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			t.Fatal(err)
+		}
+		fields := make([]string, len(values))
+		for i, v := range values {
+			switch v := v.(type) {
+			case nil:
+				fields[i] = `\N`
+			case time.Time:
+				fields[i] = v.Format("2006-01-02 15:04:05")
+			case []byte:
+				fields[i] = string(v)
+			default:
+				fields[i] = fmt.Sprint(v)
+			}
+		}
+		buf.WriteString(strings.Join(fields, ",") + "\n")
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// checkGolden compares a table dump with a golden file, or writes it with
+// -update.
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := testdataPath(t, name)
+	if *update {
+		if err := ioutil.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs:\ngot\n%s\nwant\n%s", name, got, want)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	resetTables(t)
+	rejects := filepath.Join(t.TempDir(), "rejects.tsv")
+
+	out, err := runImport(t, "--file", testdataPath(t, "counters_3g.csv"), "--workers", "2", "--batch-size", "2",
+		"--reject-file", rejects, "--no-post-load")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "COPY 7, 1 of 8 rows rejected") {
+		t.Errorf("unexpected summary %q", out)
+	}
+	checkGolden(t, "staging.golden", dumpTable(t, testStaging))
+
+	data, err := ioutil.ReadFile(rejects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "4\trrc_att\tabc\t") {
+		t.Errorf("unexpected reject %q, want line 4 for rrc_att", data)
+	}
+}
+
+// TestCopyTab loads the same rows split by tab, which COPY receives field by
+// field rather than as one line.
+func TestCopyTab(t *testing.T) {
+	resetTables(t)
+	data, err := ioutil.ReadFile(testdataPath(t, "counters_3g.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tsv := filepath.Join(t.TempDir(), "counters_3g.tsv")
+	if err := ioutil.WriteFile(tsv, bytes.Replace(data, []byte(","), []byte("\t"), -1), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := runImport(t, "--file", tsv, "--split", `\t`, "--reject-file", os.DevNull, "--no-post-load"); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "staging.golden", dumpTable(t, testStaging))
+}
+
+func TestTruncate(t *testing.T) {
+	resetTables(t)
+	load := func(args ...string) error {
+		args = append([]string{"--file", testdataPath(t, "counters_3g.csv"), "--reject-file", os.DevNull, "--no-post-load"}, args...)
+		_, err := runImport(t, args...)
+		return err
+	}
+
+	if err := load(); err != nil {
+		t.Fatal(err)
+	}
+	// The same rows again violate the staging table's primary key
+	if err := load(); err == nil {
+		t.Error("second load without --truncate succeeded")
+	}
+	if err := load("--truncate"); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "staging.golden", dumpTable(t, testStaging))
+}
+
+func TestPostLoad(t *testing.T) {
+	resetTables(t)
+
+	// The second load of the same file leaves the rollups as they were
+	for i := 0; i < 2; i++ {
+		out, err := runImport(t, "--file", testdataPath(t, "counters_3g.csv"), "--workers", "2", "--batch-size", "3",
+			"--reject-file", os.DevNull)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range []string{"hourly", "daily", "truncate-staging"} {
+			if !strings.Contains(out, "[STAGE] "+s+" ") {
+				t.Errorf("load %d did not run stage %s:\n%s", i+1, s, out)
+			}
+		}
+		checkGolden(t, "hourly.golden", dumpTable(t, testHourly))
+		checkGolden(t, "daily.golden", dumpTable(t, testDaily))
+
+		var staged int
+		if err := testConn.Get(&staged, fmt.Sprintf("SELECT count(*) FROM %s", quoteTable("public", testStaging))); err != nil {
+			t.Fatal(err)
+		}
+		if staged != 0 {
+			t.Errorf("%d rows left in the staging table", staged)
+		}
+	}
+}
+
+// TestCopyParquet loads a Parquet file whose counters are partly null.
+func TestCopyParquet(t *testing.T) {
+	resetTables(t)
+	type row struct {
+		Resulttime time.Time `parquet:"resulttime,timestamp(microsecond)"`
+		Rnc        string    `parquet:"rnc"`
+		Cellname   string    `parquet:"cellname"`
+		Ci         string    `parquet:"ci"`
+		RrcAtt     *int64    `parquet:"rrc_att,optional"`
+		RrcSucc    *int64    `parquet:"rrc_succ,optional"`
+		MeanRtwp   *float64  `parquet:"mean_rtwp,optional"`
+	}
+	att, rtwp := int64(10), -104.5
+	path := filepath.Join(t.TempDir(), "counters_3g.parquet")
+	if err := parquet.WriteFile(path, []row{
+		{time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), "RNC01", "CELLA", "101", &att, nil, &rtwp},
+		{time.Date(2024, 3, 1, 1, 0, 0, 0, time.UTC), "RNC01", "CELLA", "101", nil, nil, nil},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := runImport(t, "--input-format", "parquet", "--file", path, "--no-post-load"); err != nil {
+		t.Fatal(err)
+	}
+	want := "resulttime,unique_id,rnc,cellname,ci,rrc_att,rrc_succ,mean_rtwp\n" +
+		"2024-03-01 00:00:00,101CELLA,RNC01,CELLA,101,10,\\N,-104.5\n" +
+		"2024-03-01 01:00:00,101CELLA,RNC01,CELLA,101,\\N,\\N,\\N\n"
+	if got := dumpTable(t, testStaging); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
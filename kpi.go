@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// kpi is a named SQL expression over the counters of one hourly or daily row.
+// The identifier period_seconds stands for the length of that row's period.
+type kpi struct {
+	name string
+	expr string
+}
+
+// defaultKPIs are the standard Huawei 3G KPIs; other profiles have their own.
+var defaultKPIs = []kpi{
+	{"rrc_sr", "100 * RRCSuccConnEstabsum / VSRRCAttConnEstabSum"},
+	{"cs_rab_sr", "100 * (VSRABSuccEstabCSConv + VSRABSuccEstabCSStr) / (VSRABAttEstabCSConv + VSRABAttEstabCSStr)"},
+	{"ps_rab_sr", "100 * (VSRABSuccEstabPSConv + VSRABSuccEstabPSStr + VSRABSuccEstabPSInt + VSRABSuccEstabPSBkg) / (VSRABAttEstabPSConv + VSRABAttEstabPSStr + VSRABAttEstabPSInt + VSRABAttEstabPSBkg)"},
+	{"cs_cssr", "100 * (RRCSuccConnEstabOrgConvCall + RRCSuccConnEstabTmConvCall + RRCSuccConnEstabEmgCall) / (RRCAttConnEstabOrgConvCall + RRCAttConnEstabTmConvCall + RRCAttConnEstabEmgCall) * (VSRABSuccEstabCSConv + VSRABSuccEstabCSStr) / (VSRABAttEstabCSConv + VSRABAttEstabCSStr)"},
+	{"cs_drop_rate", "100 * VSRABAbnormRelCS / (VSRABAbnormRelCS + VSRABNormRelCS)"},
+	{"ps_drop_rate", "100 * VSRABAbnormRelPS / (VSRABAbnormRelPS + VSRABNormRelPS)"},
+	{"hsdpa_throughput_kbps", "VSHSDPAMeanChThroughputTotalBytes * 8 / 1000 / period_seconds"},
+	{"sho_sr", "100 * VSSHOSuccRLAdd / VSSHOAttRLAdd"},
+	{"irat_ho_sr_cs", "100 * IRATHOSuccOutCS / IRATHOAttOutCS"},
+	{"irat_ho_sr_ps", "100 * IRATHOSuccOutPSUTRAN / IRATHOAttOutPSUTRAN"},
+}
+
+// loadKPIs returns the KPI formulas of the --profile technology, or those read
+// from --kpi-file when given. The file
+// has one "name = expression" per line; blank lines and # comments are skipped.
+func loadKPIs() []kpi {
+	if len(kpiFile) == 0 {
+		return tech.kpis
+	}
+
+	file, err := os.Open(kpiFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	var kpis []kpi
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sp := strings.SplitN(line, "=", 2)
+		name := strings.TrimSpace(sp[0])
+		if len(sp) != 2 || name == "" || counterIdent(name) != strings.ToLower(name) {
+			log.Fatalf("Invalid KPI line %q, expected name = expression", line)
+		}
+		kpis = append(kpis, kpi{strings.ToLower(name), strings.TrimSpace(sp[1])})
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatalf("Error reading KPI file: %s", err.Error())
+	}
+	if len(kpis) == 0 {
+		log.Fatalf("No KPIs in %s", kpiFile)
+	}
+	return kpis
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '.' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// operandEnd returns the index just past the operand starting at i: a number,
+// a column, a function call or a parenthesised group, after any unary signs.
+func operandEnd(expr string, i int) int {
+	for i < len(expr) && (expr[i] == '+' || expr[i] == '-' || expr[i] == ' ') {
+		i++
+	}
+	for i < len(expr) && isIdentChar(expr[i]) {
+		i++
+	}
+	if i < len(expr) && expr[i] == '(' {
+		depth := 0
+		for ; i < len(expr); i++ {
+			switch expr[i] {
+			case '(':
+				depth++
+			case ')':
+				depth--
+				if depth == 0 {
+					return i + 1
+				}
+			}
+		}
+	}
+	return i
+}
+
+// kpiSQL renders a KPI expression for a period of the given length. Every
+// divisor is cast to numeric and wrapped in NULLIF, so integer counters do not
+// truncate and a zero attempt count yields NULL instead of an error.
+func kpiSQL(expr string, periodSeconds int) string {
+	var b strings.Builder
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == '/':
+			j := i + 1
+			for j < len(expr) && expr[j] == ' ' {
+				j++
+			}
+			end := operandEnd(expr, j)
+			if strings.Trim(expr[j:end], "+- ") == "" {
+				log.Fatalf("KPI expression %q has no divisor after /", expr)
+			}
+			fmt.Fprintf(&b, "/ NULLIF((%s)::numeric, 0)", kpiSQL(expr[j:end], periodSeconds))
+			i = end
+		case isIdentChar(c):
+			end := i
+			for end < len(expr) && isIdentChar(expr[end]) {
+				end++
+			}
+			if word := expr[i:end]; word == "period_seconds" {
+				fmt.Fprintf(&b, "%d", periodSeconds)
+			} else {
+				b.WriteString(word)
+			}
+			i = end
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// kpiColumns returns the KPI names, their expressions for the given period
+// and the SET list used to upsert them.
+func kpiColumns(kpis []kpi, periodSeconds int) (names, exprs, updates string) {
+	n := make([]string, len(kpis))
+	e := make([]string, len(kpis))
+	u := make([]string, len(kpis))
+	for i, k := range kpis {
+		n[i] = k.name
+		e[i] = kpiSQL(k.expr, periodSeconds)
+		u[i] = fmt.Sprintf("%[1]s = EXCLUDED.%[1]s", k.name)
+	}
+	return strings.Join(n, ", "), strings.Join(e, ", "), strings.Join(u, ", ")
+}
+
+// kpiTableDDL creates a KPI table, or adds columns for KPIs that are new in
+// the formula file.
+func kpiTableDDL(table, timeCol string, kpis []kpi) string {
+	cols := make([]string, len(kpis))
+	alters := make([]string, len(kpis))
+	for i, k := range kpis {
+		cols[i] = fmt.Sprintf("%s double precision", k.name)
+		alters[i] = fmt.Sprintf("ADD COLUMN IF NOT EXISTS %s double precision", k.name)
+	}
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (%[2]s timestamp NOT NULL, unique_id text NOT NULL, %[3]s text, cellname text, %[4]s text, %[5]s, PRIMARY KEY (%[2]s, unique_id));
+	ALTER TABLE %[1]s %[6]s;`, table, timeCol, tech.ne(), tech.cellID(), strings.Join(cols, ", "), strings.Join(alters, ", "))
+}
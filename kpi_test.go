@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestKPISQL(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{defaultKPIs[0].expr, "100 * RRCSuccConnEstabsum / NULLIF((VSRRCAttConnEstabSum)::numeric, 0)"},
+		{defaultKPIs[1].expr, "100 * (VSRABSuccEstabCSConv + VSRABSuccEstabCSStr) / NULLIF(((VSRABAttEstabCSConv + VSRABAttEstabCSStr))::numeric, 0)"},
+		{defaultKPIs[3].expr, "100 * (RRCSuccConnEstabOrgConvCall + RRCSuccConnEstabTmConvCall + RRCSuccConnEstabEmgCall) / NULLIF(((RRCAttConnEstabOrgConvCall + RRCAttConnEstabTmConvCall + RRCAttConnEstabEmgCall))::numeric, 0) * (VSRABSuccEstabCSConv + VSRABSuccEstabCSStr) / NULLIF(((VSRABAttEstabCSConv + VSRABAttEstabCSStr))::numeric, 0)"},
+		{defaultKPIs[4].expr, "100 * VSRABAbnormRelCS / NULLIF(((VSRABAbnormRelCS + VSRABNormRelCS))::numeric, 0)"},
+		{defaultKPIs[6].expr, "VSHSDPAMeanChThroughputTotalBytes * 8 / NULLIF((1000)::numeric, 0) / NULLIF((3600)::numeric, 0)"},
+		{"a/b/c", "a/ NULLIF((b)::numeric, 0)/ NULLIF((c)::numeric, 0)"},
+		{"a / (b + c)", "a / NULLIF(((b + c))::numeric, 0)"},
+		{"a / sum(b) * 2", "a / NULLIF((sum(b))::numeric, 0) * 2"},
+		{"a / -b", "a / NULLIF((-b)::numeric, 0)"},
+		{"a / - (b + c) + d", "a / NULLIF((- (b + c))::numeric, 0) + d"},
+	}
+	for _, tt := range tests {
+		if got := kpiSQL(tt.expr, 3600); got != tt.want {
+			t.Errorf("kpiSQL(%q)\ngot  %s\nwant %s", tt.expr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	_ "modernc.org/sqlite"
+)
+
+// Values of --sink
+const (
+	sinkPostgres = "postgres"
+	sinkCSV      = "csv"
+	sinkNDJSON   = "ndjson"
+	sinkSQLite   = "sqlite"
+)
+
+// newLocalTarget returns the target of a --sink other than postgres, which
+// writes to --sink-path instead of a database.
+func newLocalTarget() *loadTarget {
+	switch sinkName {
+	case sinkCSV, sinkNDJSON, sinkSQLite:
+	default:
+		log.Fatalf("Invalid --sink %q, expected %s, %s, %s or %s", sinkName, sinkPostgres, sinkCSV, sinkNDJSON, sinkSQLite)
+	}
+	if sinkPath == "" {
+		log.Fatalf("--sink %s needs --sink-path", sinkName)
+	}
+	if replaceRangeMode || reloadRange {
+		log.Fatalf("--replace-range and --reload-range need a database, --sink %s writes to %s", sinkName, sinkPath)
+	}
+	t := &loadTarget{name: sinkName + ":" + sinkPath}
+	switch sinkName {
+	case sinkCSV, sinkNDJSON:
+		var out *fileOutput
+		var once sync.Once
+		t.open = func() (sink, error) {
+			once.Do(func() { out = openFileOutput() })
+			return newFileSink(out), nil
+		}
+		t.close = func() {
+			if out != nil {
+				out.file.Close()
+			}
+		}
+	case sinkSQLite:
+		var db *sql.DB
+		var once sync.Once
+		t.open = func() (sink, error) {
+			once.Do(func() { db = openSQLite() })
+			return &sqliteSink{db: db, insert: sqliteInsert()}, nil
+		}
+		t.close = func() {
+			if db != nil {
+				db.Close()
+			}
+		}
+	}
+	return t
+}
+
+// fileOutput is the --sink-path file the workers of a csv or ndjson sink
+// append their committed batches to.
+type fileOutput struct {
+	mu   sync.Mutex
+	file *os.File
+	w    *bufio.Writer
+}
+
+// openFileOutput creates --sink-path, or appends to it when resuming with
+// --skip-lines. A new CSV file starts with a header line.
+func openFileOutput() *fileOutput {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if skipLines > 0 {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	file, err := os.OpenFile(sinkPath, flags, 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+	out := &fileOutput{file: file, w: bufio.NewWriter(file)}
+	if sinkName == sinkCSV && skipLines == 0 {
+		names := make([]string, len(sinkColumns))
+		for i, c := range sinkColumns {
+			names[i] = c.Name
+		}
+		w := csv.NewWriter(out.w)
+		w.Comma = []rune(splitSeparator())[0]
+		w.Write(names)
+		w.Flush()
+		if err := w.Error(); err != nil {
+			log.Fatal(err)
+		}
+	}
+	return out
+}
+
+// fileSink encodes a batch in memory and appends it to the shared file on
+// Commit, so the file holds whole batches only.
+type fileSink struct {
+	out *fileOutput
+	buf bytes.Buffer
+	csv *csv.Writer
+}
+
+func newFileSink(out *fileOutput) *fileSink {
+	s := &fileSink{out: out}
+	s.csv = csv.NewWriter(&s.buf)
+	s.csv.Comma = []rune(splitSeparator())[0]
+	return s
+}
+
+func (s *fileSink) Begin(ctx context.Context) error {
+	s.buf.Reset()
+	return nil
+}
+
+// WriteRows writes CSV with NULL as an empty field, or one JSON object per
+// line as --stream publishes it.
+func (s *fileSink) WriteRows(rows [][]string) error {
+	record := make([]string, len(sinkColumns))
+	for _, fields := range rows {
+		if len(fields) != len(sinkColumns) {
+			return fmt.Errorf("row has %d fields, expected %d columns", len(fields), len(sinkColumns))
+		}
+		if sinkName == sinkNDJSON {
+			s.buf.Write(rowJSON(fields))
+			s.buf.WriteByte('\n')
+			continue
+		}
+		for i, v := range fields {
+			record[i] = v
+			if isNull(v) {
+				record[i] = ""
+			}
+		}
+		if err := s.csv.Write(record); err != nil {
+			return err
+		}
+	}
+	s.csv.Flush()
+	return s.csv.Error()
+}
+
+func (s *fileSink) Commit() error {
+	s.out.mu.Lock()
+	defer s.out.mu.Unlock()
+	if _, err := s.out.w.Write(s.buf.Bytes()); err != nil {
+		return err
+	}
+	s.buf.Reset()
+	return s.out.w.Flush()
+}
+
+func (s *fileSink) Rollback() error {
+	s.buf.Reset()
+	return nil
+}
+
+func (s *fileSink) Close() error {
+	return nil
+}
+
+// openSQLite opens the --sink-path database and creates the --table in it if
+// missing, with the columns in COPY order. SQLite takes one writer at a time,
+// so the workers share a single connection.
+func openSQLite() *sql.DB {
+	db, err := sql.Open("sqlite", sinkPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+
+	defs := make([]string, len(sinkColumns))
+	for i, c := range sinkColumns {
+		defs[i] = sqliteIdent(c.Name) + " " + sqliteType(c.Type)
+	}
+	if _, err := db.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", sqliteIdent(tableName), strings.Join(defs, ", "))); err != nil {
+		log.Fatalf("Error creating %s in %s: %s", tableName, sinkPath, err.Error())
+	}
+	if truncate {
+		if _, err := db.Exec("DELETE FROM " + sqliteIdent(tableName)); err != nil {
+			log.Fatal(err)
+		}
+	}
+	return db
+}
+
+func sqliteIdent(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+// sqliteType maps a PostgreSQL type to a SQLite column affinity.
+func sqliteType(dataType string) string {
+	switch dataType {
+	case "smallint", "integer", "bigint":
+		return "INTEGER"
+	case "numeric", "real", "double precision":
+		return "REAL"
+	}
+	return "TEXT"
+}
+
+func sqliteInsert() string {
+	names := make([]string, len(sinkColumns))
+	for i, c := range sinkColumns {
+		names[i] = sqliteIdent(c.Name)
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", sqliteIdent(tableName), strings.Join(names, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", "))
+}
+
+// sqliteSink inserts each batch into the SQLite table in a transaction.
+type sqliteSink struct {
+	db     *sql.DB
+	insert string
+	ctx    context.Context
+	tx     *sql.Tx
+	stmt   *sql.Stmt
+}
+
+func (s *sqliteSink) Begin(ctx context.Context) error {
+	s.ctx, s.tx, s.stmt = ctx, nil, nil
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	s.tx = tx
+	s.stmt, err = tx.PrepareContext(ctx, s.insert)
+	return err
+}
+
+func (s *sqliteSink) WriteRows(rows [][]string) error {
+	args := make([]interface{}, len(sinkColumns))
+	for _, fields := range rows {
+		if len(fields) != len(sinkColumns) {
+			return fmt.Errorf("row has %d fields, expected %d columns", len(fields), len(sinkColumns))
+		}
+		for i, v := range fields {
+			args[i] = v
+			if isNull(v) {
+				args[i] = nil
+			}
+		}
+		if _, err := s.stmt.ExecContext(s.ctx, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sqliteSink) Commit() error {
+	if err := s.stmt.Close(); err != nil {
+		return err
+	}
+	return s.tx.Commit()
+}
+
+func (s *sqliteSink) Rollback() error {
+	if s.tx == nil {
+		return nil
+	}
+	return s.tx.Rollback()
+}
+
+func (s *sqliteSink) Close() error {
+	return nil
+}
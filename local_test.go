@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// localRows are two transformed rows, the second with NULL counters.
+var localRows = [][]string{
+	{"2024-03-01 00:00:00", "1ACELLA", "RNC01", "CELLA", "1A", "10", "9.5"},
+	{"2024-03-01 01:00:00", "1ACELLA", "RNC01", "CELLA", "1A", `\N`, `\N`},
+}
+
+// localTarget sets --sink and a --sink-path in a temporary directory, and
+// returns the target with the path.
+func localTarget(t *testing.T, name string) (*loadTarget, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "out."+name)
+	setFlag(t, "sink", name)
+	setFlag(t, "sink-path", path)
+	setFlag(t, "table", "counter_3g_lastday")
+	old := sinkColumns
+	sinkColumns = streamColumns
+	t.Cleanup(func() { sinkColumns = old })
+	return newLocalTarget(), path
+}
+
+// writeBatch writes rows through a new sink of the target and commits them,
+// or rolls them back.
+func writeBatch(t *testing.T, target *loadTarget, rows [][]string, commit bool) {
+	t.Helper()
+	out, err := target.open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	if err := out.Begin(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := out.WriteRows(rows); err != nil {
+		t.Fatal(err)
+	}
+	if !commit {
+		out.Rollback()
+		return
+	}
+	if err := out.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCSVSink(t *testing.T) {
+	setFlag(t, "split", ";")
+	target, path := localTarget(t, sinkCSV)
+	writeBatch(t, target, localRows[:1], true)
+	writeBatch(t, target, localRows[1:], false)
+	writeBatch(t, target, localRows[1:], true)
+	target.close()
+
+	want := "resulttime;unique_id;rnc;cellname;ci;rrc_att;mean_rtwp\n" +
+		"2024-03-01 00:00:00;1ACELLA;RNC01;CELLA;1A;10;9.5\n" +
+		"2024-03-01 01:00:00;1ACELLA;RNC01;CELLA;1A;;\n"
+	if got := readFile(t, path); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCSVSinkAppendsOnResume(t *testing.T) {
+	target, path := localTarget(t, sinkCSV)
+	if err := ioutil.WriteFile(path, []byte("header\nfirst run\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	setFlag(t, "skip-lines", "1")
+	writeBatch(t, target, localRows[:1], true)
+	target.close()
+
+	want := "header\nfirst run\n2024-03-01 00:00:00,1ACELLA,RNC01,CELLA,1A,10,9.5\n"
+	if got := readFile(t, path); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestNDJSONSink(t *testing.T) {
+	target, path := localTarget(t, sinkNDJSON)
+	writeBatch(t, target, localRows, true)
+	target.close()
+
+	want := `{"resulttime":"2024-03-01 00:00:00","unique_id":"1ACELLA","rnc":"RNC01","cellname":"CELLA","ci":"1A","rrc_att":10,"mean_rtwp":9.5}` + "\n" +
+		`{"resulttime":"2024-03-01 01:00:00","unique_id":"1ACELLA","rnc":"RNC01","cellname":"CELLA","ci":"1A","rrc_att":null,"mean_rtwp":null}` + "\n"
+	if got := readFile(t, path); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSQLiteSink(t *testing.T) {
+	target, path := localTarget(t, sinkSQLite)
+	writeBatch(t, target, localRows, true)
+	writeBatch(t, target, localRows[:1], false)
+	target.close()
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	types := make(map[string]string)
+	rows, err := db.Query(`SELECT name, type FROM pragma_table_info('counter_3g_lastday')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var name, typ string
+		if err := rows.Scan(&name, &typ); err != nil {
+			t.Fatal(err)
+		}
+		types[name] = typ
+	}
+	rows.Close()
+	for name, want := range map[string]string{"resulttime": "TEXT", "unique_id": "TEXT", "rrc_att": "INTEGER", "mean_rtwp": "REAL"} {
+		if types[name] != want {
+			t.Errorf("column %s has type %q, want %s", name, types[name], want)
+		}
+	}
+
+	var n, nulls int
+	var att int64
+	if err := db.QueryRow(`SELECT count(*), count(*) - count(rrc_att), sum(rrc_att) FROM counter_3g_lastday`).Scan(&n, &nulls, &att); err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || nulls != 1 || att != 10 {
+		t.Errorf("%d rows, %d NULL rrc_att, sum %d, want 2 rows with one NULL and 10 stored as an integer", n, nulls, att)
+	}
+
+	// A second run with --truncate keeps only its own rows
+	setFlag(t, "truncate", "true")
+	target = newLocalTarget()
+	writeBatch(t, target, localRows[:1], true)
+	target.close()
+	if err := db.QueryRow(`SELECT count(*) FROM counter_3g_lastday`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("%d rows after --truncate, want 1", n)
+	}
+}
+
+// TestLocalSinkRejectsRanges runs newLocalTarget in a child process, since it
+// exits through log.Fatalf.
+func TestLocalSinkRejectsRanges(t *testing.T) {
+	if name := os.Getenv("LOCAL_SINK_RANGE_FLAG"); name != "" {
+		flag.Set("sink", sinkCSV)
+		flag.Set("sink-path", os.DevNull)
+		flag.Set(name, "true")
+		newLocalTarget()
+		return
+	}
+	for _, name := range []string{"replace-range", "reload-range"} {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestLocalSinkRejectsRanges$")
+		cmd.Env = append(os.Environ(), "LOCAL_SINK_RANGE_FLAG="+name)
+		out, err := cmd.CombinedOutput()
+		if err == nil || !strings.Contains(string(out), "--replace-range and --reload-range need a database") {
+			t.Errorf("--sink csv --%s: %v\n%s", name, err, out)
+		}
+	}
+}
@@ -2,7 +2,7 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"flag"
 	"fmt"
 	"log"
@@ -12,7 +12,6 @@ import (
 	"sync/atomic"
 	"time"
 
-	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 )
 
@@ -23,6 +22,23 @@ var (
 	schemaName      string
 	tableName       string
 	truncate        bool
+	hourlyTable     string
+	dailyTable      string
+	timeColumn      string
+
+	maxConns         int
+	applicationName  string
+	sslMode          string
+	sslRootCert      string
+	sslCert          string
+	sslKey           string
+	statementTimeout string
+	pgbouncerMode    bool
+	extraTargets     targetList
+	streamURL        string
+	sinkName         string
+	sinkPath         string
+	streamTopicName  string
 
 	copyOptions    string
 	splitCharacter string
@@ -31,10 +47,77 @@ var (
 
 	workers         int
 	batchSize       int
+	batchBytes      int
+	targetLatency   time.Duration
+	partitionBy     string
+	partitionChunk  time.Duration
+	dedupKey        string
+	dedupPolicy     string
+	dedupWindow     int
 	logBatches      bool
 	reportingPeriod time.Duration
 	verbose         bool
 
+	noPostLoad bool
+	onlyStage  string
+
+	dryRunMode bool
+	schemaFile string
+
+	skipValidation bool
+	rejectFile     string
+
+	fromFilter       string
+	toFilter         string
+	reloadRange      bool
+	replaceRangeMode bool
+	fromTime         time.Time
+	toTime           time.Time
+
+	filteredCount int64
+
+	command            string
+	catalogueFile      string
+	chunkInterval      string
+	dailyChunkInterval string
+	compressAfter      string
+
+	hasHeader        bool
+	evolveSchemaMode bool
+	evolveType       string
+
+	dailyMode string
+
+	kpiMode        bool
+	kpiFile        string
+	kpiHourlyTable string
+	kpiDailyTable  string
+
+	dqMode     bool
+	dqTable    string
+	dqCellDrop float64
+
+	cellsMode bool
+	cellTable string
+
+	profileName    string
+	gracePeriod    time.Duration
+	skipLines      int
+	inputFormat    string
+	vendorName     string
+	counterMapFile string
+	harmonize      bool
+
+	exportLevel    string
+	outFile        string
+	rowGroupSize   int
+	rncFilter      string
+	cellList       string
+	compressOutput string
+
+	// runID identifies this run's rows in the data-quality findings
+	runID = time.Now().UTC().Format("20060102T150405Z")
+
 	columnCount int64
 	rowCount    int64
 
@@ -44,7 +127,15 @@ var (
 )
 
 type batch struct {
-	rows []string
+	rows      []string
+	firstLine int64 // lowest input line number in the batch
+	lastLine  int64 // highest input line number in the batch
+	committed []int32 // per target, set to 1 by its worker once committed there
+	pending   int32 // targets whose worker is not done with the batch yet
+	bytes     int64 // size of rows including line endings
+	// lines holds the line number of every row when rows are not consecutive
+	// input lines, i.e. with --partition-by or --dedup-key
+	lines []int64
 }
 
 func check(e error) {
@@ -53,13 +144,30 @@ func check(e error) {
     }
 }
 
-// Parse args
+// Define flags, parsed by parseArgs
 func init() {
 	flag.StringVar(&postgresConnect, "connection", "host=localhost user=postgres sslmode=disable", "PostgreSQL connection url")
 	flag.StringVar(&dbName, "db-name", "test", "Database where the destination table exists")
+	flag.Var(&extraTargets, "target", "Connection string of a further database to load each batch into as well; repeat for more")
+	flag.StringVar(&sinkName, "sink", sinkPostgres, "Where rows go instead of --connection: postgres, or csv, ndjson or sqlite written to --sink-path without a database")
+	flag.StringVar(&sinkPath, "sink-path", "", "File the csv or ndjson --sink writes, or SQLite database the sqlite --sink creates --table in")
+	flag.StringVar(&streamURL, "stream", "", "Also publish each row as JSON keyed by UNIQUE_ID: nats://host:4222 for NATS, or http(s)://host:8082 for a Kafka REST proxy")
+	flag.StringVar(&streamTopicName, "stream-topic", "", "Kafka topic, or NATS subject prefix, of --stream; the --table name if empty")
+	flag.IntVar(&maxConns, "max-conns", 0, "Most connections the run opens per target; 0 means one per worker plus one")
+	flag.StringVar(&applicationName, "application-name", "3g-data-import", "application_name the importer shows in pg_stat_activity")
+	flag.StringVar(&sslMode, "sslmode", "", "sslmode of the connection (disable, require, verify-ca, verify-full); overrides --connection")
+	flag.StringVar(&sslRootCert, "sslrootcert", "", "File of the CA certificates the server certificate is verified against")
+	flag.StringVar(&sslCert, "sslcert", "", "File of the client certificate")
+	flag.StringVar(&sslKey, "sslkey", "", "File of the client certificate's private key")
+	flag.BoolVar(&pgbouncerMode, "pgbouncer", false, "Connect through PgBouncer in transaction pooling mode: no prepared statements or session settings")
+	flag.StringVar(&statementTimeout, "statement-timeout", "", "Comma-separated phase=duration statement timeouts, phases setup, copy, post-load and export (ex. copy=5m,post-load=1h)")
 	flag.StringVar(&tableName, "table", "test_table", "Destination table for insertions")
 	flag.StringVar(&schemaName, "schema", "public", "Desination table's schema")
 	flag.BoolVar(&truncate, "truncate", false, "Truncate the destination table before insert")
+	flag.BoolVar(&replaceRangeMode, "replace-range", false, "Before insert, remove only the destination rows in the input's time range, dropping whole hypertable chunks where possible")
+	flag.StringVar(&hourlyTable, "hourly-table", "counter_3g_hourly", "Table the hourly post-load stage inserts into")
+	flag.StringVar(&dailyTable, "daily-table", "counter_3g_daily", "Table the daily post-load stage rolls up into")
+	flag.StringVar(&timeColumn, "time-column", "resulttime", "Timestamp column used for rollups and time ranges")
 
 	flag.StringVar(&copyOptions, "copy-options", "", "Additional options to pass to COPY (ex. NULL 'NULL')")
 	flag.StringVar(&splitCharacter, "split", ",", "Character to split by")
@@ -67,105 +175,306 @@ func init() {
 	flag.StringVar(&columns, "columns", "", "Comma-separated columns present in CSV")
 
 	flag.IntVar(&batchSize, "batch-size", 5000, "Number of rows per insert")
+	flag.IntVar(&batchBytes, "batch-bytes", 0, "Cut batches at this many bytes of input instead of --batch-size rows")
+	flag.DurationVar(&targetLatency, "target-latency", 0, "Adapt the batch size in bytes so a batch takes about this long to commit (starts at --batch-bytes, default 4 MiB)")
 	flag.IntVar(&workers, "workers", 1, "Number of parallel requests to make")
+	flag.StringVar(&partitionBy, "partition-by", partitionNone, "Send each worker a fixed share of the keys: 'unique-id' (hash of UNIQUE_ID) or 'time' (--partition-chunk of resulttime)")
+	flag.DurationVar(&partitionChunk, "partition-chunk", time.Hour, "Length of the resulttime chunks --partition-by time assigns to workers")
+	flag.StringVar(&dedupKey, "dedup-key", "", "Comma-separated columns identifying a row (ex. resulttime,unique_id); drop rows repeating the key of a recent row")
+	flag.StringVar(&dedupPolicy, "dedup-policy", dedupFirst, "Which of two rows with the same --dedup-key is loaded: 'first', 'last', or 'error' to stop the load")
+	flag.IntVar(&dedupWindow, "dedup-window", 100000, "How many recent rows --dedup-key compares against; bounds the memory used")
 	flag.BoolVar(&logBatches, "log-batches", false, "Whether to time individual batches.")
 	flag.DurationVar(&reportingPeriod, "reporting-period", 0*time.Second, "Period to report insert stats; if 0s, intermediate results will not be reported")
 	flag.BoolVar(&verbose, "verbose", false, "Print more information about copying statistics")
 
-	flag.Parse()
+	flag.BoolVar(&noPostLoad, "no-post-load", false, "Only COPY into the destination table, skip the post-load stages")
+	flag.StringVar(&onlyStage, "only-stage", "", "Comma-separated post-load stages to run (delete-hourly-range, delete-daily-range, hourly, daily, refresh-daily, kpi-hourly, kpi-daily, dq-*, cells, truncate-staging)")
+
+	flag.BoolVar(&dryRunMode, "dry-run", false, "Validate the input and print the statements that would run, without writing anything")
+	flag.StringVar(&schemaFile, "schema-file", "", "File of column_name,data_type lines to validate against instead of querying the database")
+	flag.BoolVar(&skipValidation, "skip-validation", false, "Do not check rows against the destination table's columns before COPY")
+	flag.StringVar(&rejectFile, "reject-file", "", "File to write rejected rows to; stderr if empty")
+
+	flag.StringVar(&fromFilter, "from", "", "Only load rows whose resulttime is at or after this time")
+	flag.StringVar(&toFilter, "to", "", "Only load rows whose resulttime is before this time")
+	flag.BoolVar(&reloadRange, "reload-range", false, "Delete the loaded time range from the hourly and daily tables before moving the new rows in")
+
+	flag.StringVar(&catalogueFile, "catalogue", "", "File of counter_name,data_type lines to use instead of the --profile's built-in counter catalogue")
+	flag.StringVar(&chunkInterval, "chunk-interval", "1 day", "init-schema: chunk interval of the hourly hypertable")
+	flag.StringVar(&dailyChunkInterval, "daily-chunk-interval", "30 days", "init-schema: chunk interval of the daily hypertable")
+	flag.StringVar(&compressAfter, "compress-after", "", "init-schema: add a compression policy for chunks older than this interval (ex. '7 days')")
+
+	flag.BoolVar(&hasHeader, "header", false, "The first input line is a header naming the columns; COPY uses it as the column list unless --columns is set")
+	flag.BoolVar(&evolveSchemaMode, "evolve-schema", false, "Add header columns missing from the tables to the staging, hourly and daily tables (needs --header)")
+	flag.StringVar(&evolveType, "evolve-type", "numeric", "Column type used for counters added by --evolve-schema")
+
+	flag.StringVar(&dailyMode, "daily-mode", dailyModeInsert, "How the daily table is maintained: 'insert' rolls up each load, 'continuous' refreshes a TimescaleDB continuous aggregate")
+
+	flag.BoolVar(&kpiMode, "kpi", false, "Maintain the hourly and daily KPI tables after the rollup")
+	flag.StringVar(&kpiFile, "kpi-file", "", "File of 'name = expression' KPI formulas to use instead of the built-in ones (implies --kpi)")
+	flag.StringVar(&kpiHourlyTable, "kpi-hourly-table", "kpi_3g_hourly", "Table the kpi-hourly stage writes to")
+	flag.StringVar(&kpiDailyTable, "kpi-daily-table", "kpi_3g_daily", "Table the kpi-daily stage writes to")
+
+	flag.BoolVar(&dqMode, "dq", false, "Run the data-quality checks after the move and write findings to --dq-table")
+	flag.StringVar(&dqTable, "dq-table", "dq_findings", "Table data-quality findings are written to")
+	flag.Float64Var(&dqCellDrop, "dq-cell-drop", 10, "Report a day whose cell count dropped by more than this percentage from the day before")
+
+	flag.BoolVar(&cellsMode, "cells", false, "Maintain the cell dimension table from the loaded rows and report new, renamed and disappeared cells")
+	flag.StringVar(&cellTable, "cell-table", "cell_3g", "Cell dimension table maintained by --cells")
+
+	flag.StringVar(&profileName, "profile", "3g", "Technology profile: 2g (BSC cells), 3g (RNC cells) or 4g (eNodeB cells); sets the key columns, catalogue, KPIs and default table names")
+	flag.DurationVar(&gracePeriod, "grace-period", 30*time.Second, "After SIGINT or SIGTERM, how long in-flight batches may take to commit before they are rolled back")
+	flag.IntVar(&skipLines, "skip-lines", 0, "Skip this many input lines after the header, e.g. to resume an interrupted load")
+	flag.StringVar(&inputFormat, "input-format", inputFormatText, "Format of --file: 'text' (delimited, or the --vendor format) or 'parquet'")
+	flag.StringVar(&vendorName, "vendor", "huawei", "Vendor profile of the input: huawei (delimited export), ericsson (3GPP XML) or nokia (OMeS XML or NetAct CSV)")
+	flag.StringVar(&counterMapFile, "counter-map", "", "File of vendor_counter,catalogue_counter lines, added to the vendor profile's built-in mapping")
+	flag.BoolVar(&harmonize, "harmonize", true, "Rename vendor counters to catalogue counters and skip unmapped ones; if false, counters keep their vendor names for vendor-specific tables")
+
+	flag.StringVar(&exportLevel, "level", "hourly", "export, export-parquet: table to export, hourly or daily")
+	flag.StringVar(&outFile, "out", "", "export, export-parquet: file to write; stdout if empty")
+	flag.StringVar(&rncFilter, "rnc", "", "export, export-parquet: comma-separated network elements (RNC, BSC or eNodeB) to export")
+	flag.StringVar(&cellList, "cell-list", "", "export, export-parquet: comma-separated cell names or UNIQUE_IDs to export, or @file with one per line")
+	flag.StringVar(&compressOutput, "compress", "", "export: compress the output, 'gzip'")
+	flag.IntVar(&rowGroupSize, "row-group-size", 100000, "export-parquet: rows per Parquet row group")
 }
 
-func getConnectString() string {
-	return fmt.Sprintf("%s dbname=%s", postgresConnect, dbName)
+// parseArgs parses the command line. It is not part of init so that tests can
+// parse their own flags.
+func parseArgs() {
+	// An optional subcommand comes before the flags
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		command = os.Args[1]
+		flag.CommandLine.Parse(os.Args[2:])
+	} else {
+		flag.Parse()
+	}
 }
 
 func getFullTableName() string {
-	return fmt.Sprintf("\"%s\".\"%s\"", schemaName, tableName)
+	return quoteTable(schemaName, tableName)
 }
 
 func main() {
+	parseArgs()
+	if dailyMode != dailyModeInsert && dailyMode != dailyModeContinuous {
+		log.Fatalf("Invalid --daily-mode %q, expected %s or %s", dailyMode, dailyModeInsert, dailyModeContinuous)
+	}
+
+	selectTechnology()
+	parseStatementTimeouts()
+	setupTargets()
+	defer closeTargets()
+	switch command {
+	case "":
+	case "init-schema":
+		initSchema()
+		return
+	case "export":
+		exportCSV()
+		return
+	case "export-parquet":
+		exportParquet()
+		return
+	default:
+		log.Fatalf("Unknown command %q", command)
+	}
+
+	if noPostLoad && onlyStage != "" {
+		log.Fatal("--no-post-load and --only-stage cannot be used together")
+	}
+	if truncate && replaceRangeMode {
+		log.Fatal("--truncate and --replace-range cannot be used together")
+	}
+	// A resumed load would remove the rows the interrupted one committed
+	if skipLines > 0 && (truncate || replaceRangeMode) {
+		log.Fatal("--skip-lines cannot be used with --truncate or --replace-range; resume without them")
+	}
+	selectVendor()
+	if profile.parse != nil && tech.name != "3g" {
+		log.Fatalf("--vendor %s only reads 3G exports", profile.name)
+	}
+	if hasHeader && profile.parse != nil {
+		log.Fatalf("--header only applies to huawei input; %s input names its own columns", profile.name)
+	}
+	if inputFormat != inputFormatText && inputFormat != inputFormatParquet {
+		log.Fatalf("Invalid --input-format %q, expected %s or %s", inputFormat, inputFormatText, inputFormatParquet)
+	}
+	if inputFormat == inputFormatParquet && (hasHeader || profile.parse != nil) {
+		log.Fatal("Parquet input names its own columns; --header and --vendor do not apply")
+	}
+	if evolveSchemaMode && !hasHeader && profile.parse == nil {
+		log.Fatal("--evolve-schema needs --header")
+	}
+	stages := selectedStages()
+	parseTimeFilters()
+
+	scanner, header, closeInput := openInput()
+	defer closeInput()
+
+	if hasHeader {
+		header = readHeader(scanner)
+	}
+	if header != nil {
+		if columns == "" {
+			columns = strings.Join(header, ",")
+		}
+		// Offline dry runs have no tables to compare against
+		if evolveSchemaMode && !(dryRunMode && len(schemaFile) > 0) {
+			eachTarget(func() { evolveSchema(header) })
+		}
+	}
+
+	sizer = newBatchSizer()
+	checkPartitionBy()
+	dedup = newDeduper()
+	if dryRunMode {
+		dryRun(scanner, stages)
+		return
+	}
+
+	handleSignals()
+	eachTarget(func() { healthCheck(getFullTableName(), "INSERT") })
+	if streamURL != "" {
+		checkStream()
+	}
 
 	f, _ = os.OpenFile("logs.txt", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	defer f.Close()
 
 	if truncate { // Remove existing data from the table
-		dbBench := sqlx.MustConnect("postgres", getConnectString())
-		_, err := dbBench.Exec(fmt.Sprintf("TRUNCATE %s", getFullTableName()))
-		if err != nil {
-			panic(err)
-		}
-
-		err = dbBench.Close()
-		if err != nil {
-			panic(err)
-		}
+		eachTarget(func() {
+			conn, ctx, release := phaseConn(phaseSetup)
+			_, err := conn.ExecContext(ctx, fmt.Sprintf("TRUNCATE %s", getFullTableName()))
+			if err != nil {
+				panic(err)
+			}
+			release()
+		})
+	} else if replaceRangeMode {
+		eachTarget(replaceRange)
 	}
 
-	var scanner *bufio.Scanner
-	if len(fromFile) > 0 {
-		file, err := os.Open(fromFile)
-		if err != nil {
-			log.Fatal(err)
+	var cols []column
+	if !skipValidation {
+		cols = loadTableColumns()
+	}
+	if streamURL != "" || sinkName != sinkPostgres {
+		sinkColumns = cols
+		if sinkColumns == nil {
+			sinkColumns = loadTableColumns()
 		}
-		defer file.Close()
-
-		scanner = bufio.NewScanner(file)
-	} else {
-		scanner = bufio.NewScanner(os.Stdin)
 	}
+	openRejects()
+	defer closeRejects()
 
 	var wg sync.WaitGroup
-	batchChan := make(chan *batch, workers)
+	// Each target's workers share one channel, or with --partition-by each
+	// has its own
+	batchChans := make([][]chan *batch, len(targets))
+	for t := range batchChans {
+		batchChans[t] = []chan *batch{make(chan *batch, workers)}
+		if partitionBy != partitionNone && workers > 1 {
+			batchChans[t] = make([]chan *batch, workers)
+			for i := range batchChans[t] {
+				batchChans[t][i] = make(chan *batch, 1)
+			}
+		}
+	}
 
 	// Generate COPY workers
-	for i := 0; i < workers; i++ {
-		wg.Add(1)
-		go processBatches(&wg, batchChan)
+	for _, t := range targets {
+		chans := batchChans[t.index]
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go processBatches(&wg, t, chans[i%len(chans)], cols, t.index*workers+i)
+		}
 	}
 
 	// Reporting thread
+	reportCtx, stopReport := context.WithCancel(stopCtx)
 	if reportingPeriod > (0 * time.Second) {
-		go report()
+		go report(reportCtx)
 	}
 
 	start := time.Now()
-	rowsRead := scan(batchSize, scanner, batchChan)
-	close(batchChan)
+	rowsRead := scan(batchSize, scanner, batchChans)
+	for _, chans := range batchChans {
+		for _, c := range chans {
+			close(c)
+		}
+	}
 	wg.Wait()
+	stopReport()
 	end := time.Now()
 	took := end.Sub(start)
 	rowRate := float64(rowsRead) / float64(took.Seconds())
 
-	res := fmt.Sprintf("COPY %d", rowsRead)
+	res := fmt.Sprintf("COPY %d", atomic.LoadInt64(&rowCount))
+	if filtered := atomic.LoadInt64(&filteredCount); filtered > 0 {
+		res += fmt.Sprintf(", %d rows outside --from/--to skipped", filtered)
+	}
+	if dedupCount > 0 {
+		res += fmt.Sprintf(", %d duplicate rows dropped", dedupCount)
+	}
+	if rejected := atomic.LoadInt64(&rejectCount); rejected > 0 {
+		res += fmt.Sprintf(", %d of %d rows rejected", rejected, rowsRead)
+	}
 	if verbose {
 		res += fmt.Sprintf(", took %v with %d worker(s) (mean rate %f/sec)", took, workers, rowRate)
+		if sizer != nil {
+			res += fmt.Sprintf(", final batch size %d bytes", sizer.limit())
+		}
 	}
 	fmt.Println(res)
-	
-	startMoving := time.Now()
-	fmt.Println("Moving data into hourly table")
-	dbBench := sqlx.MustConnect("postgres", getConnectString())
-	defer dbBench.Close()
-	dbBench.MustExec("insert into counter_3g_hourly select * from counter_3g_lastday on conflict do nothing;")
-	dbBench.MustExec("insert into counter_3g_daily select time_bucket('1 day',resulttime) tanggal, UNIQUE_ID,RNC,CELLNAME,CI,sum(VSRRCSetupConnEstab),sum(RRCSuccConnEstabsum),sum(RRCAttConnEstabOrgConvCall),sum(RRCAttConnEstabOrgStrCall),sum(RRCAttConnEstabOrgInterCall),sum(RRCAttConnEstabOrgBkgCall),sum(RRCAttConnEstabOrgSubCall),sum(RRCAttConnEstabTmConvCall),sum(RRCAttConnEstabTmStrCall),sum(RRCAttConnEstabTmInterCall),sum(RRCAttConnEstabTmBkgCall),sum(RRCAttConnEstabEmgCall),sum(RRCAttConnEstabOrgHhPrSig),sum(RRCAttConnEstabOrgLwPrSig),sum(RRCAttConnEstabCallReEst),sum(RRCAttConnEstabTmHhPrSig),sum(RRCAttConnEstabTmLwPrSig),sum(RRCAttConnEstabUnknown),sum(RRCSuccConnEstabOrgConvCall),sum(RRCSuccConnEstabOrgStrCall),sum(RRCSuccConnEstabOrgInterCall),sum(RRCSuccConnEstabOrgBkgCall),sum(RRCSuccConnEstabOrgSubCall),sum(RRCSuccConnEstabTmConvCall),sum(RRCSuccConnEstabTmStrCall),sum(RRCSuccConnEstabTmItrCall),sum(RRCSuccConnEstabTmBkgCall),sum(RRCSuccConnEstabEmgCall),sum(RRCSuccConnEstabOrgHhPrSig),sum(RRCSuccConnEstabOrgLwPrSig),sum(RRCSuccConnEstabCallReEst),sum(RRCSuccConnEstabTmHhPrSig),sum(RRCSuccConnEstabTmLwPrSig),sum(RRCSuccConnEstabUnkown),sum(VSRRCEstabDRDOutAtt),sum(VSRRCAttConnEstabSum),sum(VSRRCEstabDRDIn),sum(VSTPUE0),sum(VSTPUE1),sum(VSTPUE2),sum(VSTPUE3),sum(VSTPUE4),sum(VSTPUE5),sum(VSTPUE69),sum(VSTPUE1625),sum(VSTPUE2635),sum(VSTPUE3655),sum(VSTPUEMore55),sum(VSTPUE1015),sum(VSEcNoMeanTP0),sum(VSEcNoMeanTP1),sum(VSEcNoMeanTP2),sum(VSEcNoMeanTP3),sum(VSEcNoMeanTP4),sum(VSEcNoMeanTP5),sum(VSEcNoMeanTP69),sum(VSEcNoMeanTP1015),sum(VSEcNoMeanTP1625),sum(VSEcNoMeanTP2635),sum(VSEcNoMeanTP3655),sum(VSEcNoMeanTPMore55),sum(VSRRCRejSum),sum(VSRRCFailConnEstabCong),sum(VSRRCRejCodeCong),sum(VSRRCRejRLFail),sum(VSRRCRejTNLFail),sum(VSRRCRejRedirIntraRat),sum(VSRRCRejRedirInterRat),sum(VSRRCFailConnEstabNoReply),sum(VSRRCRejULCECong),sum(VSRRCRejDLCECong),sum(VSRRCRejULIUBBandCong),sum(VSRRCRejDLIUBBandCong),sum(VSRRCRejULPowerCong),sum(VSRRCRejDLPowerCong),sum(VSRRCRejRedirService),sum(VSLowPriRRCRanFCDiscNum),sum(VSNormPriRRCRanFCDiscNum),sum(VSHighPriRRCRanFCDiscNum),sum(VSRRCRejRedirDist),sum(VSRRCFCDiscNum),sum(VSRRCRejRedirDistIntraRat),sum(VSRRCRejNodeBResUnavail),sum(VSRRCRejRedirCoMacroMicro),sum(VSRRCRejRedirPingPongNum),sum(VSRRCRejNodeBULCECong),sum(VSRRCRejNodeBDLCECong),sum(VSRRCRejRedirWeakCoverage),sum(VSRRCFailConnEstabNoReplyCSFB),sum(VSRABAttEstabCSConv),sum(VSRABAttEstabCSStr),sum(VSRABSuccEstabCSConv),sum(VSRABSuccEstabCSStr),sum(VSRABAttEstabAMR),sum(VSRABAttEstCSConv64),sum(VSRABSuccEstabCSAMR),sum(VSRABSuccEstCSConv64),sum(VSRABSuccEstabCSAMR122),sum(VSRABAttEstabCSVPLimit),sum(VSRABAttEstabCSQueue),sum(VSRABEstabQueueTimeCS),sum(VSRABSuccEstabCSQueue),sum(VSRABFailEstabCSUnsp),sum(VSRABFailEstabCSCodeCong),sum(VSRABFailEstabCSCong),sum(VSRABFailEstabCSRNL),sum(VSRABFailEstabCSTNL),sum(VSRABFailEstabCSULCECong),sum(VSRABFailEstabCSDLCECong),sum(VSRABFailEstabCSDLIUBBandCong),sum(VSRABFailEstabCSULIUBBandCong),sum(VSRABFailEstabCSRBIncCfg),sum(VSRABFailEstabCSRBCfgUnsup),sum(VSRABFailEstabCSPhyChFail),sum(VSRABFailEstabCSUuNoReply),sum(VSRABFailEstabCSULPowerCong),sum(VSRABFailEstabCSDLPowerCong),sum(VSRABFailEstabCSIubFail),sum(VSRABFailEstabCSUuFail),sum(VSRABFailEstabCSSRBReset),sum(VSRABFailEstabCSCellUpd),sum(VSRABFailEstabCSNodeBULCECong),sum(VSRABFailEstabCSNodeBDLCECong),sum(VSRABFailEstabCSDLIUCSBandCong),sum(VSRABFailEstabCSULIUCSBandCong),sum(VSRABFailEstabCSIubAAL2Fail),sum(VSRABFailEstabCSSRBResetCSFB),sum(VSRABFailEstabCSCellUpdCSFB),sum(VSRABFailEstabCSUuFailCSFB),sum(VSRABAttRelCSNormRel),sum(VSRABAttRelCSUEInact),sum(VSRABAttRelCSPreempt),sum(VSRABAttRelCSOM),sum(VSRABAttRelCSNetOpt),sum(VSRABAttRelCSUTRANGen),sum(VSCNRABLossCS),sum(VSRABAttRelCS),sum(VSRABAttEstabPSConv),sum(VSRABAttEstabPSStr),sum(VSRABAttEstabPSInt),sum(VSRABAttEstabPSBkg),sum(VSRABSuccEstabPSConv),sum(VSRABSuccEstabPSStr),sum(VSRABSuccEstabPSInt),sum(VSRABSuccEstabPSBkg),sum(VSRABSuccEstabPS0kbps),sum(VSRABAttEstabPSQueue),sum(VSRABEstabQueueTimePS),sum(VSRABSuccEstabPSQueue),sum(VSRABSuccEstabPSPTT),sum(VSRABAttEstabPSPTT),sum(VSRABSuccEstabPSR99),sum(VSRABAttEstabPSR99),sum(VSRABAttEstabPSFree),sum(VSRABSuccEstabPSFree),sum(VSRABFailEstabPSUnsp),sum(VSRABFailEstabPSCodeCong),sum(VSRABFailEstabPSRNL),sum(VSRABFailEstabPSTNL),sum(VSRABFailEstabPSULCECong),sum(VSRABFailEstabPSDLCECong),sum(VSRABFailEstabPSDLIUBBandCong),sum(VSRABFailEstabPSULIUBBandCong),sum(VSRABFailEstabPSRBIncCfg),sum(VSRABFailEstabPSRBCfgUnsupp),sum(VSRABFailEstabPSPhyChFail),sum(VSRABFailEstabPSUuNoReply),sum(VSRABFailEstabPSULPowerCong),sum(VSRABFailEstabPSDLPowerCong),sum(VSRABFailEstabPSIubFail),sum(VSRABFailEstabPSUuFail),sum(VSRABFailEstabPSCong),sum(VSRABFailEstabPSDLPowerCongFree),sum(VSRABFailEstabPSSRBReset),sum(VSRABFailEstabPSCellUpd),sum(VSRABFailEstabPSHSUPAUserCong),sum(VSRABFailEstabPSHSDPAUserCong),sum(VSRABFailEstabPSNodeBULCECong),sum(VSRABFailEstabPSNodeBDLCECong),sum(VSRABFailEstabPSDLIUPSBandCong),sum(VSRABFailEstabPSULIUPSBandCong),sum(VSRABFailEstabPSIubAAL2Fail),sum(VSRABFailEstabPSULCEFinalCong),sum(VSRABAttRelPSNormRel),sum(VSRABAttRelPSUtranGen),sum(VSRABAttRelPSUeInact),sum(VSRABAttRelPSRABPreempt),sum(VSRABAttRelPSOM),sum(VSRABAttRelPSNetOptm),sum(VSRABAttRelPSUnsp),sum(VSCNRABLossPS),sum(VSRABAttRelPS),sum(VSRABAbnormRelCSRF),sum(VSRABAbnormRelCS),sum(VSRABNormRelCS),sum(VSRABAbnormRelPSRF),sum(VSRABAbnormRelPS),sum(VSRABNormRelPS),sum(VSRABAbnormRelCSOM),sum(VSRABAbnormRelCSUTRANgen),sum(VSRABAbnormRelCSPreempt),sum(VSRABAbnormRelPSOM),sum(VSRABAbnormRelPSPreempt),sum(VSRABAbnormRelCSRFSRBReset),sum(VSRABAbnormRelPSRFSRBReset),sum(VSRABAbnormRelPSRFTRBReset),sum(VSRABAbnormRelCSIuAAL2),sum(VSRABAbnormRelPSGTPULoss),sum(VSRABAbnormRelAMR),sum(VSRABAbnormRelCS64),sum(VSRABAbnormRelCSRFULSync),sum(VSRABAbnormRelPSRFULSync),sum(VSRABAbnormRelCSRFUuNoReply),sum(VSRABAbnormRelPSRFUuNoReply),sum(VSRABNormRelAMR),sum(VSRABAbnormRelPSOLC),sum(VSRABAbnormRelCSOLC),sum(VSRABNormRelCS64),sum(VSRABNormRelPSCCH),sum(VSRABAbnormRelPSCCH),sum(VSRABNormRelPSUEGen),sum(VSRABAbnormRelCSStr),sum(VSRABAbnormRelPSConv),sum(VSRABAbnormRelPSStr),sum(VSRABNormRelCSStr),sum(VSRABNormRelPSConv),sum(VSRABNormRelPSStr),sum(VSRABRelReqPSBEHSUPACongGolden),sum(VSRABRelReqPSBEHSUPACongSilver),sum(VSRABAbnormRelCSHSPAConv),sum(VSRABNormRelCSHSPAConv),sum(VSRABNormRelVPLimit),sum(VSRABNormRelPS0kbpsTimeout),sum(VSRABNormRelCSUEGen),sum(VSRABNormRelPSBE),sum(VSRABAbnormRelPSBE),sum(VSRABAbnormRelCS64RF),sum(VSRABAbnormRelPSPTT),sum(VSRABNormRelPSPTT),sum(VSRABAbnormRelPSR99RF),sum(VSRABAbnormRelPSR99),sum(VSRABNormRelPSR99),sum(VSRABNormRelPSPCH),sum(VSRABAbnormRelPSPCH),sum(VSRABAbnormRelPSF2P),sum(VSRABAbnormRelPSD2P),sum(VSRABAbnormRelPSR99D2P),sum(VSRABSFOccupyMAX),sum(VSMultRABSF8),sum(VSMultRABSF16),sum(VSMultRABSF32),sum(VSMultRABSF64),sum(VSSingleRABSF4),sum(VSSingleRABSF8),sum(VSSingleRABSF16),sum(VSSingleRABSF32),sum(VSSingleRABSF64),sum(VSSingleRABSF128),sum(VSSingleRABSF256),sum(VSMultRABSF4),sum(VSMultRABSF128),sum(VSMultRABSF256),sum(VSRABSFOccupy),sum(VSDRDRBSetupAttOut),sum(VSDRDRBSetupSuccOut),sum(VSDRDRBSetupAttIn),sum(VSDRDRBSetupSuccIn),sum(VSRBCSConvDL64),sum(VSRBPSIntDL8),sum(VSRBPSIntDL16),sum(VSRBPSIntDL32),sum(VSRBPSIntDL64),sum(VSRBPSIntDL128),sum(VSRBPSIntDL144),sum(VSRBPSIntDL256),sum(VSRBPSIntDL384),sum(VSRBPSIntUL8),sum(VSRBPSIntUL16),sum(VSRBPSIntUL32),sum(VSRBPSIntUL64),sum(VSRBPSIntUL128),sum(VSRBPSIntUL144),sum(VSRBPSIntUL256),sum(VSRBPSIntUL384),sum(VSRBPSBkgDL8),sum(VSRBPSBkgDL16),sum(VSRBPSBkgDL32),sum(VSRBPSBkgDL64),sum(VSRBPSBkgDL128),sum(VSRBPSBkgDL144),sum(VSRBPSBkgDL256),sum(VSRBPSBkgDL384),sum(VSRBPSBkgUL8),sum(VSRBPSBkgUL16),sum(VSRBPSBkgUL32),sum(VSRBPSBkgUL64),sum(VSRBPSBkgUL128),sum(VSRBPSBkgUL144),sum(VSRBPSBkgUL256),sum(VSRBPSBkgUL384),sum(VSRBAMRDL122),sum(VSSHOAttRLAdd),sum(VSSHOSuccRLAdd),sum(VSSHOFailRLAddCfgUnsupp),sum(VSSHOFailRLAddISR),sum(VSSHOFailRLAddInvCfg),sum(VSSHOFailRLAddNoReply),sum(VSSHOAttRLDel),sum(VSSHOSuccRLDel),sum(VSSHOAS1RL),sum(VSSHOAS2RL),sum(VSSHOAS3RL),sum(VSSHOAS4RL),sum(VSSHOAS5RL),sum(VSSHOAS6RL),sum(VSHHOAttInterFreqOut),sum(VSHHOSuccInterFreqOut),sum(VSHHOFailInterFreqOutCfgUnsupp),sum(VSHHOFailInterFreqOutPyhChFail),sum(VSHHOFailInterFreqOutISR),sum(VSHHOFailInterFreqOutCellUpdt),sum(VSHHOFailInterFreqOutInvCfg),sum(VSHHOFailInterFreqOutNoReply),sum(VSHHOFailInterFreqOutPrepFail),sum(VSHHOFailInterFreqOutRLSetupFail),sum(IRATHOAttRelocPrepOutCS),sum(IRATHOSuccRelocPrepOutCS),sum(IRATHOAttOutCS),sum(IRATHOSuccOutCS),sum(IRATHOFailOutCSCfgUnsupp),sum(IRATHOFailOutCSPhyChFail),sum(IRATHOAttOutPSUTRAN),sum(IRATHOSuccOutPSUTRAN),sum(IRATHOFailOutPSUTRANCfgUnsupp),sum(IRATHOFailOutPSUTRANPhyChFail),sum(VSIRATHOFailOutCSNoReply),sum(VSIRATHOFailOutPSUTRANNoReply),sum(VSIRATHOAttOutCSTrigRscp),sum(VSIRATHOAttOutCSTrigEcNo),sum(VSIRATHOAttOutPSTrigRscp),sum(VSIRATHOAttOutPSTrigEcNo),sum(VSIRATHOSuccOutCSTrigRscp),sum(VSIRATHOSuccOutCSTrigEcNo),sum(VSIRATHOSuccOutPSTrigRscp),sum(VSIRATHOSuccOutPSTrigEcNo),sum(VSIRATHOFailOutCSAbort),sum(VSIRATHOFailOutPSAbort),sum(VSMeanRTWP),sum(VSMeanTCP),sum(VSMaxRTWP),sum(VSMinRTWP),sum(VSMaxTCP),sum(VSMinTCP),sum(VSMaxTCPNonHS),sum(VSMinTCPNonHS),sum(VSMeanTCPNonHS),sum(VSIUBAttRLSetup),sum(VSIUBAttRLAdd),sum(VSIUBAttRLRecfg),sum(VSHSDPAD2HSucc),sum(VSHSDPAF2HSucc),sum(VSHSDPAH2DSucc),sum(VSHSDPAH2FSucc),sum(VSHSDPAMeanChThroughputTotalBytes),sum(VSHSDPASHOServCellChgAttOut),sum(VSHSDPASHOServCellChgSuccOut),sum(VSHSDPARABAttEstab),sum(VSHSDPARABSuccEstab),sum(VSHSDPAHHOH2DSuccOutIntraFreq),sum(VSHSDPAHHOH2DSuccOutInterFreq),sum(VSHSDPARABNormRelUsrInact),sum(VSHSDPARABAbnormRel),sum(VSHSDPARABAbnormRelRF),sum(VSHSDPARABNormRel),sum(VSHSDPAMeanChThroughput),sum(VSHSDPAUEMeanCell),sum(VSHSDPARABFailEstabDLPowerCong),sum(VSHSDPARABFailEstabDLIUBBandCong),sum(VSHSDPAUEMaxCell),sum(VSHSDPARABDCAttEstab),sum(VSHSDPARABDCSuccEstab),sum(VSHSDPA64QAMUEMeanCell),sum(VSHSDPADCPRIMUEMeanCell),sum(VSHSDPADCSECUEMeanCell),sum(VSHSDPARABAbnormRelH2P),sum(VSLCULCreditUsedMax),sum(VSLCULCreditUsedMin),sum(VSLCDLCreditUsedMax),sum(VSLCDLCreditUsedMin),sum(VSDCCCSuccF2P),sum(VSCellUnavailTime),sum(VSLCULCreditUsedMean),sum(VSLCDLCreditUsedMean),sum(VSCellUnavailTimeSys),sum(VSDCCCD2PSucc),sum(VSHSDPAH2PSucc),sum(VSHSUPAE2PSucc),sum(VSPSR99D2PSucc),sum(VSHSUPARABAttEstab),sum(VSHSUPARABSuccEstab),sum(VSHSUPARABAbnormRel),sum(VSHSUPARABNormRel),sum(VSHSUPAE2DSucc),sum(VSHSUPAHHOE2DSuccOutIntraFreq),sum(VSHSUPAHHOE2DSuccOutInterFreq),sum(VSHSUPAE2FSucc),sum(VSHSUPAMeanChThroughputTotalBytes),sum(VSHSUPAUEMeanCell),sum(VSHSUPAMeanChThroughput),sum(VSHSUPARABFailEstabULPowerCong),sum(VSHSUPARABFailEstabULIUBBandCong),sum(VSHSUPARABFailEstabULCECong),sum(VSHSUPAUEMaxCell),sum(VSHSUPARABAbnormRelE2P),sum(VSHSUPADCPRIMUEMeanCell),sum(VSHSUPAUEMaxTTI2ms),sum(VSHSUPAUEMaxTTI10ms),sum(VSHSUPADCSECUEMeanCell),sum(VSHSUPAUEMeanTTI2ms),sum(VSHSUPAUEMeanTTI10ms),sum(VSPSBkgDL8Traffic),sum(VSPSBkgDL16Traffic),sum(VSPSBkgDL32Traffic),sum(VSPSBkgDL64Traffic),sum(VSPSBkgDL128Traffic),sum(VSPSBkgDL144Traffic),sum(VSPSBkgDL256Traffic),sum(VSPSBkgDL384Traffic),sum(VSPSIntDL8Traffic),sum(VSPSIntDL16Traffic),sum(VSPSIntDL32Traffic),sum(VSPSIntDL64Traffic),sum(VSPSIntDL128Traffic),sum(VSPSIntDL144Traffic),sum(VSPSIntDL256Traffic),sum(VSPSIntDL384Traffic),sum(VSPSStrDL32Traffic),sum(VSPSStrDL64Traffic),sum(VSPSStrDL128Traffic),sum(VSPSStrDL144Traffic),sum(VSPSBkgUL8Traffic),sum(VSPSBkgUL16Traffic),sum(VSPSBkgUL32Traffic),sum(VSPSBkgUL64Traffic),sum(VSPSBkgUL128Traffic),sum(VSPSBkgUL144Traffic),sum(VSPSBkgUL256Traffic),sum(VSPSBkgUL384Traffic),sum(VSPSIntUL8Traffic),sum(VSPSIntUL16Traffic),sum(VSPSIntUL32Traffic),sum(VSPSIntUL64Traffic),sum(VSPSIntUL128Traffic),sum(VSPSIntUL144Traffic),sum(VSPSIntUL256Traffic),sum(VSPSIntUL384Traffic),sum(VSPSStrUL16Traffic),sum(VSPSStrUL32Traffic),sum(VSPSStrUL64Traffic),sum(VSPSBkgKbpsDL8),sum(VSPSBkgKbpsDL16),sum(VSPSBkgKbpsDL32),sum(VSPSBkgKbpsDL64),sum(VSPSBkgKbpsDL128),sum(VSPSBkgKbpsDL144),sum(VSPSBkgKbpsDL256),sum(VSPSBkgKbpsDL384),sum(VSPSIntKbpsDL8),sum(VSPSIntKbpsDL16),sum(VSPSIntKbpsDL32),sum(VSPSIntKbpsDL64),sum(VSPSIntKbpsDL128),sum(VSPSIntKbpsDL144),sum(VSPSIntKbpsDL256),sum(VSPSIntKbpsDL384),sum(VSPSStrKbpsDL32),sum(VSPSStrKbpsDL64),sum(VSPSStrKbpsDL128),sum(VSPSStrKbpsDL144),sum(VSPSBkgKbpsUL8),sum(VSPSBkgKbpsUL16),sum(VSPSBkgKbpsUL32),sum(VSPSBkgKbpsUL64),sum(VSPSBkgKbpsUL128),sum(VSPSBkgKbpsUL144),sum(VSPSBkgKbpsUL256),sum(VSPSBkgKbpsUL384),sum(VSPSIntKbpsUL8),sum(VSPSIntKbpsUL16),sum(VSPSIntKbpsUL32),sum(VSPSIntKbpsUL64),sum(VSPSIntKbpsUL128),sum(VSPSIntKbpsUL144),sum(VSPSIntKbpsUL256),sum(VSPSIntKbpsUL384),sum(VSPSStrKbpsUL16),sum(VSPSStrKbpsUL32),sum(VSPSStrKbpsUL64),sum(VSRRCPaging1LossPCHCongCell),sum(VSUTRANAttPaging1),sum(VSCellDCHUEs),sum(VSCellFACHUEs),sum(VSCellPCHUEs),sum(VSDCCCSuccF2U),sum(VSDCCCSuccD2U),sum(VSIRATHOHSDPAAttOutPSUTRAN),sum(VSIRATHOHSDPASuccOutPSUTRAN),sum(VSIRATHOHSUPASuccOutPSUTRAN),sum(VSIRATHOHSUPAAttOutPSUTRAN),sum(VSCellFACHUEsMAX),sum(VSFACHDTCHCONGTIME),sum(VSFACHDCCHCONGTIME),sum(VSFACHCCCHCONGTIME),sum(VSDCCCP2DAtt),sum(VSDCCCP2DDRDAtt),sum(VSOrigCallEstabMeanTimeAMRNB),sum(VSOrigCallEstabMeanTimeAMRWB),sum(VSAMRErlangBestCell),sum(VSRBAMRWBDL1265),sum(VSRABAttEstabCSAMRWB),sum(VSRABSuccEstabCSAMRWB),sum(VSRABAbnormRelAMRWB),sum(VSRABNormRelAMRWB),sum(VSRRCFCNumFACHCong),sum(VSSuccCellUpdtOrgConvCallPCH),sum(VSSuccCellUpdtTmConvCallPCH),sum(VSSuccCellUpdtEmgCallPCH),sum(VSAttCellUpdtOrgConvCallPCH),sum(VSAttCellUpdtTmConvCallPCH),sum(VSVPErlangBestCell),sum(VSPSBEkbitsUL032BestCell),sum(VSPSBEkbitsUL3264BestCell),sum(VSPSBEkbitsUL64144BestCell),sum(VSPSBEkbitsUL144384BestCell),sum(VSSuccEstabPSAfterP2F),sum(VSAttEstabPSAfterP2F),sum(VSSuccEstabPSAfterP2D),sum(VSAttEstabPSAfterP2D),sum(VSSuccRecfgF2HDataTransTrig),sum(VSSuccRecfgP2HDataTransTrig),sum(VSAttRecfgF2HDataTransTrig),sum(VSAttRecfgP2HDataTransTrig),sum(VSSuccRecfgF2EDataTransTrig),sum(VSSuccRecfgP2EDataTransTrig),sum(VSAttRecfgF2EDataTransTrig),sum(VSAttRecfgP2EDataTransTrig),sum(VSSRNCIubBytesPSR99StrRx),sum(VSSRNCIubBytesPSR99IntRx),sum(VSSRNCIubBytesPSR99BkgRx),sum(VSSRNCIubBytesPSR99ConvRx),sum(VSCRNCIubBytesPSR99CCHRx),sum(VSSRNCIubBytesPSR99StrTx),sum(VSSRNCIubBytesPSR99IntTx),sum(VSSRNCIubBytesPSR99BkgTx),sum(VSSRNCIubBytesPSR99ConvTx),sum(VSCRNCIubBytesPSR99CCHTx),sum(VSSRNCIubBytesHSDPATx),sum(VSSRNCIubBytesPSEFACHTx),sum(VSSRNCIubBytesHSUPARx),sum(VSAttCellUpdtEmgCallPCH),sum(VSRABAbnormRelAMR795),sum(VSRABAbnormRelAMR122),sum(VSRABAbnormRelAMR59),sum(VSRABAbnormRelAMR475),sum(VSRABAbnormRelAMRRF),sum(VSRABAbnormRelCSOthers),sum(VSRABAbnormRelCSIuTNL),sum(VSRABAbnormRelCSIuupFail),sum(VSRABAbnormRelCSCN),sum(VSRABAbnormRelCSCSFB),sum(VSRABAbnormRelCSCSFBRF),sum(VSRABAbnormRelCSPlatinum),sum(VSRABAbnormRelCSSecurity),sum(VSRABAbnormRelPSOthers),sum(VSRABAbnormRelPSUTRANgen),sum(VSRABAbnormRelPSIuTNL),sum(VSRABAbnormRelPSRFOthers),sum(VSRABAbnormRelPSCN),sum(VSRABAbnormRelPSSecurity),sum(VSRABAbnormRelPSR99D2F),sum(VSRABAbnormRelPSR99CellDCHCellUpdt),sum(VSHSDPARABAbnormRel64QAM),sum(VSHSDPARABAbnormRel64QAM2P),sum(VSHSDPARABAbnormRelCellDCHCellUpdt),sum(VSHSDPARABAbnormRelDC),sum(VSHSDPARABAbnormRelDC2P),sum(VSHSDPARABAbnormRelDCMIMO2P),sum(VSHSDPARABAbnormRelH2F),sum(VSHSDPARABAbnormRelSRBoH),sum(VSHSDPARABAbnormRelSRBoHH2P),sum(VSHHOInterFreqOutCSDrop),sum(VSHHOIntraFreqOutDrop),sum(VSHHOInterFreqOutPSDrop),sum(VSRRCRejRedirIntraRatCSService),sum(VSRRCRejRedirIntraRatPSService),sum(VSRRCRejRedirInterRatCSService),sum(VSRRCRejRedirInterRatPSService),sum(VSSHOFailRLAddIubHW),sum(VSIRATHOFailOutCSCNUnspecFail),sum(VSIRATHOFailOutCSInterRatRF),sum(VSIRATHOFailOutCSSCRI),sum(VSIRATHOFailOutPSUTRANCNUnspecFail),sum(VSIRATHOFailOutPSUTRANInterRatRF),sum(VSIRATHOFailOutPSUTRANNoSRNSDataForwardCmd),sum(VSIRATHOFailOutPSUTRANSCRI),sum(VSIRATHOFailOutPS),sum(VSIRATHOFailOutPSUEGen),sum(VSHHOFailInterFreqOutInterRNCCellUpdt),sum(VSHHOFailInterFreqOutInterRNCCfgUnsupp),sum(VSHHOFailInterFreqOutInterRNCInvCfg),sum(VSHHOFailInterFreqOutInterRNCISR),sum(VSHHOFailInterFreqOutInterRNCNoReply),sum(VSHHOFailInterFreqOutInterRNCPhyChFail),sum(VSRRCPaging1PCHCongCSPreemptAtt),sum(VSIUBFailRLRecfgCong),sum(VSHSUPARABAbnormRelRF),sum(VSHSDPAD2HAtt),sum(VSHSUPAD2ESucc),sum(VSHSUPAD2EAtt),sum(VSPSBEkbitsDL032BestCell),sum(VSPSBEkbitsDL3264BestCell),sum(VSPSBEkbitsDL64144BestCell),sum(VSPSBEkbitsDL144384BestCell),sum(VSHSUPAGoldenBeMeanChThroughputTotalBytes),sum(VSHSUPASilverBeMeanChThroughputTotalBytes),sum(VSHSUPACopperBeMeanChThroughputTotalBytes),sum(VSRRCSuccConnEstabCSFB),sum(VSRRCAttConnEstabCSFB),sum(VSRABSuccEstabCSCSFBRedir),sum(VSRABAttEstabCSCSFBRedir) from counter_3g_lastday group by tanggal, UNIQUE_ID, RNC,CELLNAME, CI on conflict do nothing;")
-	dbBench.MustExec("TRUNCATE counter_3g_lastday")
-	endMoving := time.Now()
-	movingDuration := endMoving.Sub(startMoving)
-	fmt.Println(fmt.Sprintf("Data has been moved successfully in %v seconds)", movingDuration))
+	if fanOut() {
+		for _, t := range targets {
+			t.summary()
+		}
+	}
+
+	// Post-load runs on each database that received every batch
+	incomplete := false
+	for _, t := range targets {
+		postLoad := t.database && !noPostLoad
+		if fanOut() && postLoad {
+			fmt.Printf("[TARGET] %s\n", t.name)
+		}
+		if !allCommitted(t.index) {
+			printResume(t)
+			if postLoad {
+				fmt.Println("Not all batches were committed, skipping post-load stages")
+			}
+			incomplete = true
+		} else if postLoad {
+			current = t
+			runPostLoad(stages)
+		}
+	}
+	current = primaryDatabase()
+	if incomplete {
+		os.Exit(1)
+	}
 }
 
 // report periodically prints the write rate in number of rows per second
-func report() {
+// until ctx is done.
+func report(ctx context.Context) {
 	start := time.Now()
 	prevTime := start
 	prevRowCount := int64(0)
 
-	for now := range time.NewTicker(reportingPeriod).C {
+	ticker := time.NewTicker(reportingPeriod)
+	defer ticker.Stop()
+	for {
+		var now time.Time
+		select {
+		case <-ctx.Done():
+			return
+		case now = <-ticker.C:
+		}
 		rCount := atomic.LoadInt64(&rowCount)
 
-		took := now.Sub(prevTime)
-		rowrate := float64(rCount-prevRowCount) / float64(took.Seconds())
-		overallRowrate := float64(rCount) / float64(now.Sub(start).Seconds())
+		rowrate, overallRowrate := rowRates(rCount, prevRowCount, now.Sub(prevTime), now.Sub(start))
 		totalTook := now.Sub(start)
 
 		fmt.Printf("at %v, row rate %f/sec (period), row rate %f/sec (overall), %E total rows\n", totalTook-(totalTook%time.Second), rowrate, overallRowrate, float64(rCount))
@@ -176,19 +485,119 @@ func report() {
 
 }
 
-// scan reads lines from a bufio.Scanner, each which should be in CSV format
-// with a delimiter specified by --split (comma by default)
-func scan(itemsPerBatch int, scanner *bufio.Scanner, batchChan chan *batch) int64 {
-	rows := make([]string, 0, itemsPerBatch)
+// rowRates returns the rows per second over the last reporting period, in
+// which the row count went from prevRows to rows, and over the whole run.
+func rowRates(rows, prevRows int64, period, total time.Duration) (float64, float64) {
+	return float64(rows-prevRows) / period.Seconds(), float64(rows) / total.Seconds()
+}
+
+// scan reads lines from a lineSource, each which should be in CSV format
+// with a delimiter specified by --split (comma by default). Every batch goes to
+// each target's channels. With more than one channel per target, rows are
+// partitioned by --partition-by and each channel gets only its own rows. It
+// stops early when the run is interrupted.
+func scan(itemsPerBatch int, scanner lineSource, batchChans [][]chan *batch) int64 {
+	partitions := len(batchChans[0])
+	partitioned := partitions > 1
+	pending := make([]*batch, partitions)
 	var linesRead int64
+	lineOffset := int64(0) // reported line numbers count the header
+	if hasHeader {
+		lineOffset = 1
+	}
+	nextLine = lineOffset + 1
+
+	// stopAt makes nextLine the first line of any batch not dispatched
+	stopAt := func() {
+		for _, b := range pending {
+			if b != nil && b.firstLine < nextLine {
+				nextLine = b.firstLine
+			}
+		}
+		if dedup != nil {
+			if held := dedup.firstHeld(); held > 0 && held < nextLine {
+				nextLine = held
+			}
+		}
+	}
+	// dispatch hands a batch to its worker of each target unless the run is
+	// stopped first
+	dispatch := func(p int) bool {
+		b := pending[p]
+		for t, chans := range batchChans {
+			select {
+			case chans[p] <- b:
+				if t == 0 {
+					dispatched = append(dispatched, b)
+				}
+			case <-stopCtx.Done():
+				stopAt()
+				return false
+			}
+		}
+		pending[p] = nil
+		return true
+	}
+
+	sChar := splitSeparator()
+	// add appends a row to its batch and dispatches the batch once full
+	add := func(line string, lineNo int64) bool {
+		p := 0
+		if partitioned {
+			p = partitionOf(line, sChar, partitions)
+		}
+		b := pending[p]
+		if b == nil {
+			b = &batch{rows: make([]string, 0, itemsPerBatch), firstLine: lineNo, committed: make([]int32, len(batchChans)),
+				pending: int32(len(batchChans))}
+			pending[p] = b
+		}
+		if lineNo < b.firstLine { // rows held back by --dedup-policy last
+			b.firstLine = lineNo
+		}
+		if lineNo > b.lastLine {
+			b.lastLine = lineNo
+		}
+		b.rows = append(b.rows, line)
+		b.bytes += int64(len(line)) + 1
+		if partitioned || dedup != nil {
+			b.lines = append(b.lines, lineNo)
+		}
+
+		full := len(b.rows) >= itemsPerBatch
+		if sizer != nil {
+			full = sizer.full(b.bytes)
+		}
+		return !full || dispatch(p) // dispatch to COPY worker
+	}
 
 	for scanner.Scan() {
+		if interrupted() {
+			stopAt()
+			return linesRead
+		}
 		linesRead++
+		nextLine = linesRead + lineOffset + 1
+		if linesRead <= int64(skipLines) {
+			continue
+		}
+
+		line := scanner.Text()
+		if !inTimeRange(line, sChar) {
+			atomic.AddInt64(&filteredCount, 1)
+			continue
+		}
 
-		rows = append(rows, scanner.Text())
-		if len(rows) >= itemsPerBatch { // dispatch to COPY worker & reset
-			batchChan <- &batch{rows}
-			rows = make([]string, 0, itemsPerBatch)
+		if dedup == nil {
+			if !add(line, linesRead+lineOffset) {
+				return linesRead
+			}
+			continue
+		}
+		for _, row := range dedup.push(line, sChar, linesRead+lineOffset) {
+			if !add(row.line, row.lineNo) {
+				return linesRead
+			}
 		}
 	}
 
@@ -196,61 +605,139 @@ func scan(itemsPerBatch int, scanner *bufio.Scanner, batchChan chan *batch) int6
 		log.Fatalf("Error reading input: %s", err.Error())
 	}
 
-	// Finished reading input, make sure last batch goes out.
-	if len(rows) > 0 {
-		batchChan <- &batch{rows}
+	// Finished reading input, make sure last batches go out.
+	if dedup != nil {
+		for _, row := range dedup.flush() {
+			if !add(row.line, row.lineNo) {
+				return linesRead
+			}
+		}
+	}
+	for p, b := range pending {
+		if b != nil && !dispatch(p) {
+			return linesRead
+		}
 	}
+	inputDone = true
 
 	return linesRead
 }
 
-// processBatches reads batches from C and writes them to the target server, while tracking stats on the write.
-func processBatches(wg *sync.WaitGroup, C chan *batch) {
-	dbBench := sqlx.MustConnect("postgres", getConnectString())
-	defer dbBench.Close()
-	columnCountWorker := int64(0)
-	for batch := range C {
-		start := time.Now()
-
-		tx := dbBench.MustBegin()
-		delimStr := fmt.Sprintf("'%s'", splitCharacter)
-		if splitCharacter == "\\t" {
-			delimStr = "E" + delimStr
+// parseTimeFilters parses --from and --to.
+func parseTimeFilters() {
+	var err error
+	if len(fromFilter) > 0 {
+		if fromTime, err = parseTimestamp(fromFilter); err != nil {
+			log.Fatalf("Invalid --from %q: %s", fromFilter, err.Error())
 		}
-		var copyCmd string
-		if columns != "" {
-			copyCmd = fmt.Sprintf("COPY %s(%s) FROM STDIN WITH DELIMITER %s %s", getFullTableName(), columns, delimStr, copyOptions)
-		} else {
-			copyCmd = fmt.Sprintf("COPY %s FROM STDIN WITH DELIMITER %s %s", getFullTableName(), delimStr, copyOptions)
+	}
+	if len(toFilter) > 0 {
+		if toTime, err = parseTimestamp(toFilter); err != nil {
+			log.Fatalf("Invalid --to %q: %s", toFilter, err.Error())
 		}
-		
-		//fmt.Println(copyCmd)
+	}
+	if !fromTime.IsZero() && !toTime.IsZero() && !fromTime.Before(toTime) {
+		log.Fatal("--from must be before --to")
+	}
+}
 
-		stmt, err := tx.Prepare(copyCmd)
-		if err != nil {
-			panic(err)
-		}
+// inTimeRange reports whether the resulttime of a raw input line, its first
+// field, falls in [--from, --to). Lines whose time cannot be parsed are kept so
+// that validation can reject them.
+func inTimeRange(line, sep string) bool {
+	if fromTime.IsZero() && toTime.IsZero() {
+		return true
+	}
+	field := line
+	if i := strings.Index(line, sep); i >= 0 {
+		field = line[:i]
+	}
+	t, err := parseTimestamp(field)
+	if err != nil {
+		return true
+	}
+	if !fromTime.IsZero() && t.Before(fromTime) {
+		return false
+	}
+	if !toTime.IsZero() && !t.Before(toTime) {
+		return false
+	}
+	return true
+}
+
+// copyCommand builds the COPY statement every worker prepares for its batches.
+// lib/pq sends the fields of a row tab-separated whatever --split is, so the
+// COPY keeps the default delimiter.
+func copyCommand() string {
+	cmd := "COPY " + getFullTableName()
+	if columns != "" {
+		cmd += "(" + columns + ")"
+	}
+	cmd += " FROM STDIN"
+	if copyOptions != "" {
+		cmd += " WITH " + copyOptions
+	}
+	return cmd
+}
+
+// splitSeparator converts the string-ified --split value to the actual character for a correct split
+func splitSeparator() string {
+	if splitCharacter == "\\t" {
+		return "\t"
+	}
+	return splitCharacter
+}
+
+// transformLine splits an input line and inserts the UNIQUE_ID column after the
+// timestamp. UNIQUE_ID is derived by the --profile technology, for 3G the
+// concatenation of the fourth and third input fields.
+func transformLine(line, sep string) ([]string, error) {
+	sp := strings.Split(line, sep)
+	if len(sp) < 4 {
+		return nil, fmt.Errorf("line has %d fields, need at least 4 to build UNIQUE_ID", len(sp))
+	}
+
+	fields := make([]string, 0, len(sp)+1)
+	fields = append(fields, sp[0], tech.uniqueID(sp))
+	return append(fields, sp[1:]...), nil
+}
 
-		// Need to cover the string-ified version of the character to actual character for correct split
-		sChar := splitCharacter
-		if sChar == "\\t" {
-			sChar = "\t"
+// processBatches reads batches from C and writes them to the target's sink, while tracking stats on the write.
+func processBatches(wg *sync.WaitGroup, t *loadTarget, C chan *batch, cols []column, worker int) {
+	defer wg.Done()
+	if fanOut() {
+		defer t.recoverWorker(C)
+	}
+	out, err := t.open()
+	if err != nil {
+		panic(err)
+	}
+	defer out.Close()
+	columnCountWorker := int64(0)
+	for batch := range C {
+		// Batches still queued when the run is stopped, or for a target that
+		// failed, are not started
+		if interrupted() || t.failed() != nil {
+			batch.release()
+			continue
 		}
-		
-		//fmt.Println(sChar)
-		//os.Exit(3)
-		
-		for _, line := range batch.rows {
-			sp := strings.Split(line, sChar)
+		start := time.Now()
 
-			var unique_id = sp[3] + sp[2]
-			slice_1 := make([]string, 2)
-			slice_1[0] = sp[0]
-			slice_1[1] = unique_id
+		sChar := splitSeparator()
+		rows := make([][]string, 0, len(batch.rows))
+		for i, line := range batch.rows {
+			new_sp, err := profile.transform(line, sChar)
+			if err == nil && !skipValidation {
+				err = validateRow(new_sp, cols)
+			}
+			if err != nil {
+				// Every target gets the same rows, report them once
+				if t.index == 0 {
+					reject(batch.lineNo(i), line, err)
+				}
+				continue
+			}
 
-			var slice_2 []string = sp[1:]
-			new_sp := append(slice_1, slice_2...)
-			
 			finalCommand := strings.Join(new_sp, ",")
 			
 			if _, err := f.WriteString(finalCommand + "\n+++++++++++++++++++++++++++++++++++++++++++++++++\n"); err != nil {
@@ -258,41 +745,54 @@ func processBatches(wg *sync.WaitGroup, C chan *batch) {
 			}
 
 			columnCountWorker += int64(len(new_sp))
-			// For some reason this is only needed for tab splitting
-			if sChar == "\t" {
-				args := make([]interface{}, len(new_sp))
-				for i, v := range new_sp {
-					args[i] = v
-				}
-				_, err = stmt.Exec(args...)
-			} else {
-				//fmt.Println("Masuk else")
-				_, err = stmt.Exec(finalCommand)
-			}
+			rows = append(rows, new_sp)
+		}
 
-			if err != nil {
-				panic(err)
+		// abortCtx rolls the batch back when the grace period is over
+		rolledBack := func(err error) bool {
+			if err == nil || abortCtx.Err() == nil {
+				return false
 			}
+			out.Rollback()
+			fmt.Fprintf(os.Stderr, "[BATCH] line %d rolled back, grace period over\n", batch.firstLine)
+			columnCountWorker = 0
+			return true
 		}
-		atomic.AddInt64(&columnCount, columnCountWorker)
-		atomic.AddInt64(&rowCount, int64(len(batch.rows)))
-		columnCountWorker = 0
-
-		err = stmt.Close()
-		if err != nil {
-			panic(err)
+		err := out.Begin(abortCtx)
+		if err == nil {
+			err = out.WriteRows(rows)
+		}
+		if err == nil {
+			err = out.Commit()
+		}
+		if rolledBack(err) {
+			batch.release()
+			continue
 		}
-
-		err = tx.Commit()
 		if err != nil {
+			out.Rollback()
+			batch.release()
 			panic(err)
 		}
+		atomic.StoreInt32(&batch.committed[t.index], 1)
+		atomic.AddInt64(&t.rows, int64(len(rows)))
+		if t.index == 0 {
+			atomic.AddInt64(&columnCount, columnCountWorker)
+			atomic.AddInt64(&rowCount, int64(len(rows)))
+		}
+		columnCountWorker = 0
 
+		took := time.Now().Sub(start)
+		if sizer != nil {
+			sizer.observe(worker, batch.bytes, took)
+		}
 		if logBatches {
-			took := time.Now().Sub(start)
-			fmt.Printf("[BATCH] took %v, batch size %d, row rate %f/sec\n", took, batchSize, float64(batchSize)/float64(took.Seconds()))
+			fmt.Printf("[BATCH] took %v, batch size %d, row rate %f/sec", took, len(batch.rows), float64(len(batch.rows))/float64(took.Seconds()))
+			if sizer != nil {
+				fmt.Printf(", %d bytes, next batch %d bytes", batch.bytes, sizer.limit())
+			}
+			fmt.Println()
 		}
-
+		batch.release()
 	}
-	wg.Done()
 }
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"math"
+	"os"
+	"os/exec"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// setFlag sets a command line flag for the duration of a test.
+func setFlag(t *testing.T, name, value string) {
+	t.Helper()
+	f := flag.Lookup(name)
+	if f == nil {
+		t.Fatalf("no flag --%s", name)
+	}
+	old := f.Value.String()
+	if err := f.Value.Set(value); err != nil {
+		t.Fatalf("--%s %q: %s", name, value, err)
+	}
+	t.Cleanup(func() { f.Value.Set(old) })
+}
+
+// resetScan clears the state scan leaves behind.
+func resetScan(t *testing.T) {
+	reset := func() {
+		dispatched, nextLine, inputDone = nil, 0, false
+		dedup, sizer = nil, nil
+		fromTime, toTime = time.Time{}, time.Time{}
+		filteredCount, dedupCount = 0, 0
+		stopCtx = context.Background()
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+// discardOutput sends the rows processBatches logs and the rejects to
+// os.DevNull for the duration of a test.
+func discardOutput(t *testing.T) {
+	t.Helper()
+	out, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldF, oldRejects := f, rejectOut
+	f, rejectOut = out, out
+	t.Cleanup(func() {
+		f, rejectOut = oldF, oldRejects
+		out.Close()
+	})
+}
+
+// scanLines runs scan over lines with one target and the given number of
+// channels, and returns what each channel received.
+func scanLines(t *testing.T, batchSize, channels int, lines ...string) (int64, [][]*batch) {
+	t.Helper()
+	chans := make([]chan *batch, channels)
+	for i := range chans {
+		chans[i] = make(chan *batch, len(lines)+1)
+	}
+	scanner := bufio.NewScanner(strings.NewReader(strings.Join(lines, "\n")))
+	read := scan(batchSize, scanner, [][]chan *batch{chans})
+
+	got := make([][]*batch, channels)
+	for i, c := range chans {
+		close(c)
+		for b := range c {
+			got[i] = append(got[i], b)
+		}
+	}
+	return read, got
+}
+
+func cellLine(hour int, cell string) string {
+	return time.Date(2024, 3, 1, hour, 0, 0, 0, time.UTC).Format("2006-01-02 15:04:05") + ",RNC01," + cell + ",1" + cell[len(cell)-1:] + ",10,9"
+}
+
+func TestScanBatches(t *testing.T) {
+	resetScan(t)
+	var lines []string
+	for h := 0; h < 7; h++ {
+		lines = append(lines, cellLine(h, "CELLA"))
+	}
+
+	read, got := scanLines(t, 3, 1, lines...)
+	if read != 7 {
+		t.Errorf("read %d lines, want 7", read)
+	}
+	var sizes []int
+	var firstLines []int64
+	for _, b := range got[0] {
+		sizes = append(sizes, len(b.rows))
+		firstLines = append(firstLines, b.firstLine)
+		if len(b.committed) != 1 {
+			t.Errorf("batch has %d committed flags, want one per target", len(b.committed))
+		}
+	}
+	if !reflect.DeepEqual(sizes, []int{3, 3, 1}) {
+		t.Errorf("batch sizes %v, want [3 3 1]", sizes)
+	}
+	if !reflect.DeepEqual(firstLines, []int64{1, 4, 7}) {
+		t.Errorf("first lines %v, want [1 4 7]", firstLines)
+	}
+	if got[0][1].rows[0] != lines[3] {
+		t.Errorf("second batch starts with %q, want %q", got[0][1].rows[0], lines[3])
+	}
+	if !inputDone || len(dispatched) != 3 {
+		t.Errorf("inputDone %v with %d batches dispatched, want true with 3", inputDone, len(dispatched))
+	}
+}
+
+func TestScanBatchBytes(t *testing.T) {
+	resetScan(t)
+	line := cellLine(0, "CELLA")
+	limit := 2 * (len(line) + 1)
+	setFlag(t, "batch-bytes", strconv.Itoa(limit))
+	sizer = newBatchSizer()
+
+	_, got := scanLines(t, 1000, 1, line, line, line, line, line)
+	if len(got[0]) != 3 || len(got[0][0].rows) != 2 || got[0][0].bytes != int64(limit) {
+		t.Fatalf("got %d batches, want 2+2+1 rows cut at %d bytes", len(got[0]), limit)
+	}
+}
+
+func TestScanSkipLinesAfterHeader(t *testing.T) {
+	resetScan(t)
+	setFlag(t, "header", "true")
+	setFlag(t, "skip-lines", "2")
+
+	// The header was read before scan, so the lines start at line 2
+	read, got := scanLines(t, 10, 1, cellLine(0, "CELLA"), cellLine(1, "CELLA"), cellLine(2, "CELLA"), cellLine(3, "CELLA"))
+	if read != 4 {
+		t.Errorf("read %d lines, want 4", read)
+	}
+	b := got[0][0]
+	if len(b.rows) != 2 || b.firstLine != 4 || b.lineNo(1) != 5 {
+		t.Errorf("got %d rows from line %d, want 2 from line 4", len(b.rows), b.firstLine)
+	}
+	if nextLine != 6 {
+		t.Errorf("nextLine %d, want 6", nextLine)
+	}
+}
+
+func TestScanTimeFilter(t *testing.T) {
+	resetScan(t)
+	fromTime = time.Date(2024, 3, 1, 1, 0, 0, 0, time.UTC)
+	toTime = time.Date(2024, 3, 1, 3, 0, 0, 0, time.UTC)
+
+	_, got := scanLines(t, 10, 1, cellLine(0, "CELLA"), cellLine(1, "CELLA"), cellLine(2, "CELLA"), cellLine(3, "CELLA"), "garbage")
+	b := got[0][0]
+	want := []string{cellLine(1, "CELLA"), cellLine(2, "CELLA"), "garbage"}
+	if !reflect.DeepEqual(b.rows, want) {
+		t.Errorf("rows %q, want %q", b.rows, want)
+	}
+	if filteredCount != 2 {
+		t.Errorf("filtered %d rows, want 2", filteredCount)
+	}
+}
+
+func TestScanPartitionByUniqueID(t *testing.T) {
+	resetScan(t)
+	setFlag(t, "partition-by", partitionUniqueID)
+
+	var lines []string
+	for h := 0; h < 6; h++ {
+		lines = append(lines, cellLine(h, "CELLA"), cellLine(h, "CELLB"), cellLine(h, "CELLC"))
+	}
+	_, got := scanLines(t, 4, 3, lines...)
+
+	owner := make(map[string]int)
+	rows := 0
+	for p, batches := range got {
+		for _, b := range batches {
+			for i, line := range b.rows {
+				rows++
+				if lines[b.lineNo(i)-1] != line {
+					t.Errorf("row %q recorded as line %d", line, b.lineNo(i))
+				}
+				cell := strings.Split(line, ",")[2]
+				if prev, ok := owner[cell]; ok && prev != p {
+					t.Errorf("%s went to workers %d and %d", cell, prev, p)
+				}
+				owner[cell] = p
+			}
+		}
+	}
+	if rows != len(lines) {
+		t.Errorf("got %d rows, want %d", rows, len(lines))
+	}
+}
+
+func TestScanDedup(t *testing.T) {
+	resetScan(t)
+	dedup = &deduper{keys: []int{0, 1}, policy: dedupFirst, seen: make(map[string]int64), window: make([]dedupRow, 10)}
+
+	_, got := scanLines(t, 10, 1, cellLine(0, "CELLA"), cellLine(0, "CELLB"), cellLine(0, "CELLA"), cellLine(1, "CELLA"))
+	b := got[0][0]
+	if len(b.rows) != 3 || b.lineNo(2) != 4 {
+		t.Errorf("got rows %q, want lines 1, 2 and 4", b.rows)
+	}
+	if dedupCount != 1 {
+		t.Errorf("dropped %d rows, want 1", dedupCount)
+	}
+}
+
+func TestScanInterrupted(t *testing.T) {
+	resetScan(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	stopCtx = ctx
+
+	read, got := scanLines(t, 10, 1, cellLine(0, "CELLA"), cellLine(1, "CELLA"))
+	if read != 0 || len(got[0]) != 0 {
+		t.Errorf("read %d lines into %d batches after the stop, want none", read, len(got[0]))
+	}
+	if inputDone || nextLine != 1 {
+		t.Errorf("inputDone %v, nextLine %d, want false and 1", inputDone, nextLine)
+	}
+}
+
+func TestBatchRowsReleased(t *testing.T) {
+	resetScan(t)
+	discardOutput(t)
+	defer func(ts []*loadTarget) { targets = ts }(targets)
+	out := &memorySink{}
+	copied := &loadTarget{index: 0, name: "copied", open: func() (sink, error) { return out, nil }}
+	failed := &loadTarget{index: 1, name: "failed", open: func() (sink, error) { return out, nil }, err: "down"}
+	targets = []*loadTarget{copied, failed}
+
+	chans := [][]chan *batch{{make(chan *batch, 3)}, {make(chan *batch, 3)}}
+	scanner := bufio.NewScanner(strings.NewReader(strings.Join([]string{
+		cellLine(0, "CELLA"), cellLine(1, "CELLA"), cellLine(2, "CELLA"), cellLine(3, "CELLA"), cellLine(4, "CELLA")}, "\n")))
+	scan(2, scanner, chans)
+	cols := []column{{"resulttime", "timestamp"}, {"unique_id", "text"}, {"rnc", "text"},
+		{"cellname", "text"}, {"ci", "text"}, {"c1", "bigint"}, {"c2", "bigint"}}
+	run := func(t *loadTarget, C chan *batch) {
+		close(C)
+		var wg sync.WaitGroup
+		wg.Add(1)
+		processBatches(&wg, t, C, cols, 0)
+	}
+
+	run(copied, chans[0][0])
+	if len(out.committed) != 5 {
+		t.Fatalf("committed %d rows, want 5", len(out.committed))
+	}
+	for _, b := range dispatched {
+		if b.rows == nil {
+			t.Fatalf("batch from line %d released before the failed target was done with it", b.firstLine)
+		}
+	}
+	run(failed, chans[1][0])
+	for _, b := range dispatched {
+		if b.rows != nil || b.lines != nil {
+			t.Errorf("batch from line %d still holds its rows", b.firstLine)
+		}
+	}
+
+	if line, after := resumeLine(0); line != 6 || after != 0 || !allCommitted(0) {
+		t.Errorf("copied target resumes at %d with %d later batches, want 6 and 0", line, after)
+	}
+	if line, _ := resumeLine(1); line != 1 || allCommitted(1) {
+		t.Errorf("failed target resumes at %d, want 1", line)
+	}
+}
+
+// TestSkipLinesRejectsSetup runs main in a child process, since it exits
+// through log.Fatal.
+func TestSkipLinesRejectsSetup(t *testing.T) {
+	if setup := os.Getenv("SKIP_LINES_SETUP_FLAG"); setup != "" {
+		os.Args = []string{"3g-data-import", "--skip-lines", "100", setup}
+		main()
+		return
+	}
+	for _, setup := range []string{"--truncate", "--replace-range"} {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestSkipLinesRejectsSetup$")
+		cmd.Env = append(os.Environ(), "SKIP_LINES_SETUP_FLAG="+setup)
+		out, err := cmd.CombinedOutput()
+		if err == nil || !strings.Contains(string(out), "--skip-lines cannot be used with --truncate or --replace-range") {
+			t.Errorf("--skip-lines 100 %s: %v\n%s", setup, err, out)
+		}
+	}
+}
+
+func TestTransformLine(t *testing.T) {
+	defer func(t *technology) { tech = t }(tech)
+
+	tests := []struct {
+		profile string
+		line    string
+		sep     string
+		want    []string
+	}{
+		{"3g", "2024-03-01 00:00:00,RNC01,CELLA,101,10,9", ",",
+			[]string{"2024-03-01 00:00:00", "101CELLA", "RNC01", "CELLA", "101", "10", "9"}},
+		{"3g", "2024-03-01 00:00:00\tRNC01\tCELLA\t101\t10", "\t",
+			[]string{"2024-03-01 00:00:00", "101CELLA", "RNC01", "CELLA", "101", "10"}},
+		{"2g", "2024-03-01 00:00:00,BSC01,CELLA,4711", ",",
+			[]string{"2024-03-01 00:00:00", "4711CELLA", "BSC01", "CELLA", "4711"}},
+		{"4g", "2024-03-01 00:00:00,ENB01,CELLA,1,7", ",",
+			[]string{"2024-03-01 00:00:00", "ENB01_1", "ENB01", "CELLA", "1", "7"}},
+	}
+	for _, tt := range tests {
+		tech = technologies[tt.profile]
+		got, err := transformLine(tt.line, tt.sep)
+		if err != nil {
+			t.Errorf("%s %q: %s", tt.profile, tt.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s %q: got %q, want %q", tt.profile, tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestTransformLineTooFewFields(t *testing.T) {
+	for _, tt := range []struct{ line, sep string }{
+		{"2024-03-01 00:00:00,RNC01,CELLA", ","},
+		// A comma separated line split by tab is a single field
+		{"2024-03-01 00:00:00,RNC01,CELLA,101,10", "\t"},
+	} {
+		if got, err := transformLine(tt.line, tt.sep); err == nil {
+			t.Errorf("%q split by %q: got %q, want an error", tt.line, tt.sep, got)
+		}
+	}
+}
+
+func TestSplitSeparator(t *testing.T) {
+	for _, tt := range []struct{ split, sep string }{
+		{",", ","},
+		{";", ";"},
+		{`\t`, "\t"},
+	} {
+		setFlag(t, "split", tt.split)
+		if got := splitSeparator(); got != tt.sep {
+			t.Errorf("--split %s: separator %q, want %q", tt.split, got, tt.sep)
+		}
+		// The fields reach COPY one by one, never split by --split
+		if got := copyCommand(); strings.Contains(got, "DELIMITER") {
+			t.Errorf("--split %s: %q sets a delimiter", tt.split, got)
+		}
+	}
+}
+
+func TestCopyCommandColumns(t *testing.T) {
+	setFlag(t, "table", "counter_3g_lastday")
+	setFlag(t, "columns", "resulttime,unique_id,rnc")
+
+	want := `COPY "public"."counter_3g_lastday"(resulttime,unique_id,rnc) FROM STDIN`
+	if got := copyCommand(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	setFlag(t, "copy-options", "NULL 'NULL'")
+	if got := copyCommand(); got != want+" WITH NULL 'NULL'" {
+		t.Errorf("got %q, want the --copy-options after WITH", got)
+	}
+}
+
+func TestCopyArgs(t *testing.T) {
+	got := copyArgs([]string{"2024-03-01 00:00:00", "101CELLA", `\N`, "", "a\\b", "10"})
+	want := []interface{}{"2024-03-01 00:00:00", "101CELLA", nil, nil, "a\\b", "10"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestRowRates(t *testing.T) {
+	tests := []struct {
+		rows, prevRows  int64
+		period, total   time.Duration
+		periodRate, all float64
+	}{
+		{100, 40, 2 * time.Second, 10 * time.Second, 30, 10},
+		{0, 0, time.Second, time.Second, 0, 0},
+		{5000, 5000, 500 * time.Millisecond, time.Minute, 0, 5000.0 / 60},
+		{1500, 0, 1500 * time.Millisecond, 1500 * time.Millisecond, 1000, 1000},
+	}
+	for _, tt := range tests {
+		periodRate, all := rowRates(tt.rows, tt.prevRows, tt.period, tt.total)
+		if math.Abs(periodRate-tt.periodRate) > 1e-9 || math.Abs(all-tt.all) > 1e-9 {
+			t.Errorf("rowRates(%d, %d, %v, %v) = %f, %f, want %f, %f", tt.rows, tt.prevRows, tt.period, tt.total,
+				periodRate, all, tt.periodRate, tt.all)
+		}
+	}
+}
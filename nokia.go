@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// nokiaTimestampLayouts are the PERIOD_START_TIME formats of NetAct CSV reports.
+var nokiaTimestampLayouts = []string{
+	"01.02.2006 15:04:05",
+	"01.02.2006 15:04",
+}
+
+// nokiaKeyColumns are the header names of the CSV key columns, normalized by
+// counterIdent with underscores removed.
+var nokiaKeyColumns = map[string][]string{
+	"time":     {"periodstarttime"},
+	"rnc":      {"rncname", "rnc"},
+	"cellname": {"wcelname", "cellname"},
+	"ci":       {"ci", "cellid", "wcelci"},
+}
+
+// nokiaCounterMap maps Nokia RNC counters, by the names NetAct reports show,
+// to the catalogue counters used by the rollup, KPIs and data-quality checks.
+// Drops count only the radio cause of the active RAB failures.
+var nokiaCounterMap = map[string]string{
+	"RRC_CONN_STP_ATT":            "VSRRCAttConnEstabSum",
+	"RRC_CONN_ACC_COMP":           "RRCSuccConnEstabsum",
+	"RAB_STP_ATT_CS_VOICE":        "VSRABAttEstabCSConv",
+	"RAB_ACC_COMP_CS_VOICE":       "VSRABSuccEstabCSConv",
+	"RAB_STP_ATT_CS_STREA":        "VSRABAttEstabCSStr",
+	"RAB_ACC_COMP_CS_STREA":       "VSRABSuccEstabCSStr",
+	"RAB_STP_ATT_PS_STREA":        "VSRABAttEstabPSStr",
+	"RAB_ACC_COMP_PS_STREA":       "VSRABSuccEstabPSStr",
+	"RAB_STP_ATT_PS_INTER":        "VSRABAttEstabPSInt",
+	"RAB_ACC_COMP_PS_INTER":       "VSRABSuccEstabPSInt",
+	"RAB_STP_ATT_PS_BACKG":        "VSRABAttEstabPSBkg",
+	"RAB_ACC_COMP_PS_BACKG":       "VSRABSuccEstabPSBkg",
+	"RAB_ACT_FAIL_CS_VOICE_RADIO": "VSRABAbnormRelCS",
+	"RAB_ACT_COMP_CS_VOICE":       "VSRABNormRelCS",
+	"RAB_ACT_FAIL_PS_INTER_RADIO": "VSRABAbnormRelPS",
+	"RAB_ACT_COMP_PS_INTER":       "VSRABNormRelPS",
+	"CELL_ADD_REQ_ON_SHO_FOR_RT":  "VSSHOAttRLAdd",
+	"SUCC_UPDATES_ON_SHO_FOR_RT":  "VSSHOSuccRLAdd",
+	"IS_HHO_ATT_RT":               "IRATHOAttOutCS",
+	"SUCC_IS_HHO_RT":              "IRATHOSuccOutCS",
+	"IS_HHO_ATT_NRT":              "IRATHOAttOutPSUTRAN",
+	"SUCC_IS_HHO_NRT":             "IRATHOSuccOutPSUTRAN",
+}
+
+// omes is a Nokia OMeS XML measurement file.
+type omes struct {
+	PMSetup []struct {
+		StartTime  string `xml:"startTime,attr"`
+		PMMOResult []struct {
+			DN       []string `xml:"MO>DN"`
+			PMTarget []struct {
+				Counters []struct {
+					XMLName xml.Name
+					Value   string `xml:",chardata"`
+				} `xml:",any"`
+			} `xml:"PMTarget"`
+		} `xml:"PMMOResult"`
+	} `xml:"PMSetup"`
+}
+
+// parseNokia reads a Nokia export, OMeS XML or a NetAct CSV report.
+func parseNokia(r io.Reader, mapping *counterMapping) (*pmRows, error) {
+	br := bufio.NewReader(r)
+	start, err := br.Peek(64)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(start), []byte("<")) {
+		return parseNokiaXML(br, mapping)
+	}
+	return parseNokiaCSV(br, mapping)
+}
+
+// parseNokiaCSV reads a NetAct report with a header line, separated by
+// semicolons or commas. UNIQUE_ID is rnc/ci, or rnc/cellname without a CI
+// column; every other column is a counter.
+func parseNokiaCSV(r io.Reader, mapping *counterMapping) (*pmRows, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("empty input")
+	}
+	headerLine := scanner.Text()
+	sep := ","
+	if strings.Contains(headerLine, ";") {
+		sep = ";"
+	}
+	header := strings.Split(headerLine, sep)
+
+	keys := map[string]int{"time": -1, "rnc": -1, "cellname": -1, "ci": -1}
+	isKey := make([]bool, len(header))
+	for i, h := range header {
+		name := strings.ReplaceAll(counterIdent(h), "_", "")
+		for key, aliases := range nokiaKeyColumns {
+			for _, a := range aliases {
+				if name == a && keys[key] < 0 {
+					keys[key] = i
+					isKey[i] = true
+				}
+			}
+		}
+	}
+	if keys["time"] < 0 || keys["rnc"] < 0 || keys["cellname"] < 0 && keys["ci"] < 0 {
+		return nil, fmt.Errorf("header needs PERIOD_START_TIME, RNC name and WCEL name or CI columns")
+	}
+
+	rows := newPMRows(mapping)
+	lineNo := 1
+	for scanner.Scan() {
+		lineNo++
+		sp := strings.Split(scanner.Text(), sep)
+		if len(sp) != len(header) {
+			return nil, fmt.Errorf("line %d has %d fields, header has %d", lineNo, len(sp), len(header))
+		}
+		t, err := parseNokiaTime(strings.TrimSpace(sp[keys["time"]]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", lineNo, err.Error())
+		}
+
+		field := func(key string) string {
+			if keys[key] < 0 {
+				return ""
+			}
+			return strings.TrimSpace(sp[keys[key]])
+		}
+		cell := pmCell{rnc: field("rnc"), cellname: field("cellname"), ci: field("ci")}
+		if cell.ci == "" {
+			// Without a CI column the WCEL name identifies the cell
+			cell.ci = cell.cellname
+		}
+		cell.uniqueID = cell.rnc + "/" + cell.ci
+
+		for i, v := range sp {
+			if !isKey[i] {
+				rows.set(t, cell, strings.TrimSpace(header[i]), v)
+			}
+		}
+	}
+	return rows, scanner.Err()
+}
+
+func parseNokiaTime(v string) (time.Time, error) {
+	for _, layout := range nokiaTimestampLayouts {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, nil
+		}
+	}
+	return parseTimestamp(v)
+}
+
+// parseNokiaXML reads an OMeS file. The cell comes from the DN of each result,
+// e.g. PLMN-PLMN/RNC-12/WBTS-3/WCEL-4: rnc is the RNC id, ci the WCEL id,
+// cellname the whole DN and UNIQUE_ID rnc/ci. Results for objects other than
+// WCEL are skipped.
+func parseNokiaXML(r io.Reader, mapping *counterMapping) (*pmRows, error) {
+	var doc omes
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	rows := newPMRows(mapping)
+	for _, setup := range doc.PMSetup {
+		t, err := parseOMeSTime(setup.StartTime)
+		if err != nil {
+			return nil, fmt.Errorf("PMSetup startTime: %s", err.Error())
+		}
+		for _, res := range setup.PMMOResult {
+			if len(res.DN) == 0 {
+				continue
+			}
+			dn := strings.TrimSpace(res.DN[0])
+			parts := cellFromPairs(dn, "/", "-")
+			if parts["wcel"] == "" {
+				continue
+			}
+			cell := pmCell{uniqueID: parts["rnc"] + "/" + parts["wcel"], rnc: parts["rnc"], cellname: dn, ci: parts["wcel"]}
+			for _, target := range res.PMTarget {
+				for _, c := range target.Counters {
+					rows.set(t, cell, c.XMLName.Local, c.Value)
+				}
+			}
+		}
+	}
+	return rows, nil
+}
+
+// parseOMeSTime reads startTime values such as 2024-01-01T10:00:00.000+07:00:00,
+// whose zone offset has seconds.
+func parseOMeSTime(v string) (time.Time, error) {
+	if n := len(v); n > 9 && (v[n-9] == '+' || v[n-9] == '-') && v[n-3] == ':' {
+		v = v[:n-3]
+	}
+	return time.Parse("2006-01-02T15:04:05.999999999Z07:00", v)
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/format"
+)
+
+// Values of --input-format
+const (
+	inputFormatText    = "text"
+	inputFormatParquet = "parquet"
+)
+
+// parquetReadRows is how many rows are decoded from a row group at a time.
+const parquetReadRows = 1024
+
+// lineSource is what scan reads lines from: a bufio.Scanner over text input,
+// or a parquetSource rendering Parquet rows as delimited lines.
+type lineSource interface {
+	Scan() bool
+	Text() string
+	Err() error
+}
+
+// parquetSource reads a Parquet file one row group at a time and renders each
+// row as a --split delimited line. The time column comes first, since the time
+// filters, --partition-by time and --replace-range read it from the first
+// field, and the other columns follow in file order. When the file has no
+// unique_id column it is derived like for text input and inserted after the
+// time column.
+type parquetSource struct {
+	file    *os.File
+	groups  []parquet.RowGroup
+	rows    parquet.Rows
+	buf     []parquet.Row
+	n, i    int
+	line    string
+	err     error
+	sep     string
+	columns []string
+	logical []*format.LogicalType
+	order   []int // input column of each output column
+
+	// deriveKeys holds the input columns of the time, network element, cell
+	// name and cell id when unique_id has to be derived, nil otherwise
+	deriveKeys []int
+	values     []string
+	fields     []string
+}
+
+// parquetProfile splits the lines of Parquet input, which are rendered with
+// their unique_id already.
+var parquetProfile = &vendorProfile{name: inputFormatParquet, transform: splitLine}
+
+// openParquet opens --file and works out the columns its lines will have.
+func openParquet() *parquetSource {
+	if len(fromFile) == 0 {
+		log.Fatal("--input-format parquet needs --file, Parquet cannot be read from stdin")
+	}
+	file, err := os.Open(fromFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		log.Fatal(err)
+	}
+	pf, err := parquet.OpenFile(file, stat.Size())
+	if err != nil {
+		log.Fatalf("Error opening Parquet file: %s", err.Error())
+	}
+
+	src := &parquetSource{file: file, groups: pf.RowGroups(), buf: make([]parquet.Row, parquetReadRows), sep: splitSeparator()}
+	var names []string
+	index := make(map[string]int)
+	for i, path := range pf.Schema().Columns() {
+		leaf, _ := pf.Schema().Lookup(path...)
+		name := strings.ToLower(strings.Join(path, "_"))
+		names = append(names, name)
+		src.logical = append(src.logical, leaf.Node.Type().LogicalType())
+		index[name] = i
+	}
+
+	timeIdx, ok := index[timeColumn]
+	if !ok {
+		log.Fatalf("Parquet file has no %s column", timeColumn)
+	}
+	src.order = []int{timeIdx}
+	for i := range names {
+		if i != timeIdx {
+			src.order = append(src.order, i)
+		}
+	}
+	for _, i := range src.order {
+		src.columns = append(src.columns, names[i])
+	}
+
+	if _, ok := index["unique_id"]; !ok {
+		keys := []string{timeColumn, tech.keys[2].Name, tech.keys[3].Name, tech.keys[4].Name}
+		for _, k := range keys {
+			i, ok := index[k]
+			if !ok {
+				log.Fatalf("Parquet file has neither unique_id nor %s to derive it from", strings.Join(keys, ", "))
+			}
+			src.deriveKeys = append(src.deriveKeys, i)
+		}
+		src.columns = append(src.columns[:1], append([]string{"unique_id"}, src.columns[1:]...)...)
+	}
+	return src
+}
+
+func (s *parquetSource) Scan() bool {
+	for s.i >= s.n {
+		if s.rows == nil {
+			if len(s.groups) == 0 {
+				return false
+			}
+			s.rows = s.groups[0].Rows()
+			s.groups = s.groups[1:]
+		}
+		n, err := s.rows.ReadRows(s.buf)
+		s.n, s.i = n, 0
+		if err == io.EOF {
+			s.rows.Close()
+			s.rows = nil
+		} else if err != nil {
+			s.err = err
+			return false
+		}
+	}
+	s.line = s.render(s.buf[s.i])
+	s.i++
+	return true
+}
+
+func (s *parquetSource) Text() string {
+	return s.line
+}
+
+func (s *parquetSource) Err() error {
+	return s.err
+}
+
+func (s *parquetSource) Close() {
+	if s.rows != nil {
+		s.rows.Close()
+	}
+	s.file.Close()
+}
+
+func (s *parquetSource) render(row parquet.Row) string {
+	s.values = s.values[:0]
+	for _, v := range row {
+		s.values = append(s.values, parquetValue(v, s.logical[v.Column()]))
+	}
+	s.fields = s.fields[:0]
+	for _, i := range s.order {
+		s.fields = append(s.fields, s.values[i])
+	}
+	if s.deriveKeys != nil {
+		sp := make([]string, len(s.deriveKeys))
+		for i, k := range s.deriveKeys {
+			sp[i] = s.values[k]
+		}
+		s.fields = append(s.fields[:1], append([]string{tech.uniqueID(sp)}, s.fields[1:]...)...)
+	}
+	return strings.Join(s.fields, s.sep)
+}
+
+// parquetValue formats a value the way COPY's text format reads it.
+func parquetValue(v parquet.Value, logical *format.LogicalType) string {
+	if v.IsNull() {
+		return `\N`
+	}
+	switch v.Kind() {
+	case parquet.Boolean:
+		return strconv.FormatBool(v.Boolean())
+	case parquet.Int32:
+		if logical != nil && logical.Date != nil {
+			return time.Unix(int64(v.Int32())*86400, 0).UTC().Format("2006-01-02")
+		}
+		return strconv.FormatInt(int64(v.Int32()), 10)
+	case parquet.Int64:
+		if logical != nil && logical.Timestamp != nil {
+			var t time.Time
+			switch unit := logical.Timestamp.Unit; {
+			case unit.Millis != nil:
+				t = time.UnixMilli(v.Int64())
+			case unit.Nanos != nil:
+				t = time.Unix(0, v.Int64())
+			default:
+				t = time.UnixMicro(v.Int64())
+			}
+			return t.UTC().Format(pgTimestampLayout)
+		}
+		return strconv.FormatInt(v.Int64(), 10)
+	case parquet.Float:
+		return strconv.FormatFloat(float64(v.Float()), 'g', -1, 32)
+	case parquet.Double:
+		return strconv.FormatFloat(v.Double(), 'g', -1, 64)
+	}
+	return string(v.ByteArray())
+}
+
+// parquetNode maps a PostgreSQL result column to an optional Parquet column.
+func parquetNode(dbType string) parquet.Node {
+	switch dbType {
+	case "TIMESTAMP", "TIMESTAMPTZ":
+		return parquet.Optional(parquet.Timestamp(parquet.Microsecond))
+	case "DATE":
+		return parquet.Optional(parquet.Date())
+	case "INT2", "INT4", "INT8":
+		return parquet.Optional(parquet.Int(64))
+	case "FLOAT4", "FLOAT8", "NUMERIC":
+		return parquet.Optional(parquet.Leaf(parquet.DoubleType))
+	case "BOOL":
+		return parquet.Optional(parquet.Leaf(parquet.BooleanType))
+	}
+	return parquet.Optional(parquet.String())
+}
+
+// parquetCell converts a scanned PostgreSQL value to a Parquet value.
+func parquetCell(v interface{}, dbType string) (parquet.Value, error) {
+	if v == nil {
+		return parquet.NullValue(), nil
+	}
+	switch x := v.(type) {
+	case time.Time:
+		if dbType == "DATE" {
+			return parquet.Int32Value(int32(x.Unix() / 86400)), nil
+		}
+		return parquet.Int64Value(x.UnixMicro()), nil
+	case int64:
+		return parquet.Int64Value(x), nil
+	case float64:
+		return parquet.DoubleValue(x), nil
+	case bool:
+		return parquet.BooleanValue(x), nil
+	case []byte:
+		if dbType == "NUMERIC" {
+			f, err := strconv.ParseFloat(string(x), 64)
+			return parquet.DoubleValue(f), err
+		}
+		return parquet.ByteArrayValue(x), nil
+	case string:
+		return parquet.ByteArrayValue([]byte(x)), nil
+	}
+	return parquet.Value{}, fmt.Errorf("unsupported value %T", v)
+}
+
+// exportParquet writes the rows of the hourly or daily table matching the
+// export filters to --out. Rows are streamed from the query and flushed every
+// --row-group-size rows, so memory use is bounded by one row group.
+func exportParquet() {
+	table, timeCol := exportSource()
+	parseTimeFilters()
+	query, args := exportQuery(table, timeCol, fromTime, toTime)
+
+	out := os.Stdout
+	if len(outFile) > 0 {
+		var err error
+		if out, err = os.Create(outFile); err != nil {
+			log.Fatal(err)
+		}
+		defer out.Close()
+	}
+
+	healthCheck(quoteTable(schemaName, table), "SELECT")
+	conn, ctx, release := phaseConn(phaseExport)
+	defer release()
+
+	start := time.Now()
+	rows, err := conn.QueryxContext(ctx, query, args...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer rows.Close()
+
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		log.Fatal(err)
+	}
+	group := make(parquet.Group, len(colTypes))
+	dbTypes := make([]string, len(colTypes))
+	for i, ct := range colTypes {
+		dbTypes[i] = ct.DatabaseTypeName()
+		group[ct.Name()] = parquetNode(dbTypes[i])
+	}
+	schema := parquet.NewSchema(table, group)
+	// Group columns are ordered by name, not by the query's column order
+	leaves := make([]int, len(colTypes))
+	for i, ct := range colTypes {
+		leaf, _ := schema.Lookup(ct.Name())
+		leaves[i] = leaf.ColumnIndex
+	}
+
+	writer := parquet.NewWriter(out, schema)
+	var written, inGroup int64
+	pending := make([]parquet.Row, 0, parquetReadRows)
+	flushRows := func() {
+		if _, err := writer.WriteRows(pending); err != nil {
+			log.Fatalf("Error writing Parquet: %s", err.Error())
+		}
+		pending = pending[:0]
+	}
+
+	for rows.Next() {
+		values, err := rows.SliceScan()
+		if err != nil {
+			log.Fatal(err)
+		}
+		row := make(parquet.Row, len(values))
+		for i, v := range values {
+			pv, err := parquetCell(v, dbTypes[i])
+			if err != nil {
+				log.Fatalf("Column %s: %s", colTypes[i].Name(), err.Error())
+			}
+			level := 1
+			if v == nil {
+				level = 0
+			}
+			row[leaves[i]] = pv.Level(0, level, leaves[i])
+		}
+		pending = append(pending, row)
+		written++
+		inGroup++
+
+		if len(pending) == cap(pending) {
+			flushRows()
+		}
+		if inGroup >= int64(rowGroupSize) {
+			flushRows()
+			if err := writer.Flush(); err != nil {
+				log.Fatalf("Error writing Parquet: %s", err.Error())
+			}
+			inGroup = 0
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Fatal(err)
+	}
+	flushRows()
+	if err := writer.Close(); err != nil {
+		log.Fatalf("Error writing Parquet: %s", err.Error())
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported %d rows from %s in %v\n", written, quoteTable(schemaName, table), time.Now().Sub(start))
+}
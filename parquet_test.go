@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetRow has the time column after the cell keys and no unique_id, so the
+// source has to move the one and derive the other.
+type parquetRow struct {
+	Rnc        string    `parquet:"rnc"`
+	Cellname   string    `parquet:"cellname"`
+	Ci         string    `parquet:"ci"`
+	Resulttime time.Time `parquet:"resulttime,timestamp(microsecond)"`
+	RrcAtt     *int64    `parquet:"rrc_att,optional"`
+}
+
+// writeParquet writes rows to a Parquet file and points --file at it.
+func writeParquet(t *testing.T, rows ...parquetRow) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "counters.parquet")
+	if err := parquet.WriteFile(path, rows); err != nil {
+		t.Fatal(err)
+	}
+	setFlag(t, "input-format", inputFormatParquet)
+	setFlag(t, "file", path)
+}
+
+// memorySink keeps the rows of the batches committed to it.
+type memorySink struct {
+	mu        sync.Mutex
+	pending   [][]string
+	committed [][]string
+}
+
+func (s *memorySink) Begin(ctx context.Context) error { return nil }
+
+func (s *memorySink) WriteRows(rows [][]string) error {
+	s.pending = append(s.pending, rows...)
+	return nil
+}
+
+func (s *memorySink) Commit() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.committed = append(s.committed, s.pending...)
+	s.pending = nil
+	return nil
+}
+
+func (s *memorySink) Rollback() error {
+	s.pending = nil
+	return nil
+}
+
+func (s *memorySink) Close() error { return nil }
+
+// loadParquet reads --file through openInput, scan and processBatches into a
+// memory sink, and returns the header and the committed rows.
+func loadParquet(t *testing.T) ([]string, [][]string) {
+	t.Helper()
+	defer func(p *vendorProfile, ts []*loadTarget) { profile, targets = p, ts }(profile, targets)
+	discardOutput(t)
+
+	src, header, closeInput := openInput()
+	defer closeInput()
+
+	out := &memorySink{}
+	target := &loadTarget{name: "memory", open: func() (sink, error) { return out, nil }}
+	targets = []*loadTarget{target}
+	C := make(chan *batch, 10)
+	scan(2, src, [][]chan *batch{{C}})
+	close(C)
+
+	cols := []column{{timeColumn, "timestamp without time zone"}, {"unique_id", "text"},
+		{"rnc", "text"}, {"cellname", "text"}, {"ci", "text"}, {"rrc_att", "bigint"}}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	processBatches(&wg, target, C, cols, 0)
+	return header, out.committed
+}
+
+func TestParquetLoad(t *testing.T) {
+	att := int64(10)
+	writeParquet(t,
+		parquetRow{"RNC01", "CELLA", "101", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), &att},
+		parquetRow{"RNC01", "CELLB", "102", time.Date(2024, 3, 1, 1, 0, 0, 0, time.UTC), nil},
+		parquetRow{"RNC01", "CELLA", "101", time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC), &att})
+
+	resetScan(t)
+	header, rows := loadParquet(t)
+	if want := []string{timeColumn, "unique_id", "rnc", "cellname", "ci", "rrc_att"}; !reflect.DeepEqual(header, want) {
+		t.Errorf("header %q, want %q", header, want)
+	}
+	want := [][]string{
+		{"2024-03-01 00:00:00", "101CELLA", "RNC01", "CELLA", "101", "10"},
+		{"2024-03-01 01:00:00", "102CELLB", "RNC01", "CELLB", "102", `\N`},
+		{"2024-03-01 02:00:00", "101CELLA", "RNC01", "CELLA", "101", "10"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows %q, want %q", rows, want)
+	}
+	// The COPY sink loads the null counter as NULL, not as the text \N
+	if args := copyArgs(rows[1]); args[5] != nil {
+		t.Errorf("COPY argument %#v for a null counter, want nil", args[5])
+	}
+}
+
+func TestParquetTimeFilter(t *testing.T) {
+	att := int64(10)
+	writeParquet(t,
+		parquetRow{"RNC01", "CELLA", "101", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), &att},
+		parquetRow{"RNC01", "CELLA", "101", time.Date(2024, 3, 1, 1, 0, 0, 0, time.UTC), &att},
+		parquetRow{"RNC01", "CELLA", "101", time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC), &att})
+
+	// The time filters read the first field, which resulttime is moved to
+	resetScan(t)
+	fromTime = time.Date(2024, 3, 1, 1, 0, 0, 0, time.UTC)
+	_, rows := loadParquet(t)
+	if len(rows) != 2 || rows[0][0] != "2024-03-01 01:00:00" {
+		t.Errorf("rows %q, want the two from 01:00", rows)
+	}
+	if filteredCount != 1 {
+		t.Errorf("filtered %d rows, want 1", filteredCount)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"hash/fnv"
+	"log"
+	"strings"
+)
+
+// Values of --partition-by
+const (
+	partitionNone     = ""
+	partitionUniqueID = "unique-id"
+	partitionTime     = "time"
+)
+
+func checkPartitionBy() {
+	switch partitionBy {
+	case partitionNone, partitionUniqueID, partitionTime:
+	default:
+		log.Fatalf("Invalid --partition-by %q, expected %s or %s", partitionBy, partitionUniqueID, partitionTime)
+	}
+	if partitionBy == partitionTime && partitionChunk <= 0 {
+		log.Fatal("--partition-chunk must be positive")
+	}
+}
+
+// partitionOf returns the worker that owns a line's key: a hash of its
+// UNIQUE_ID, or its --partition-chunk of resulttime with consecutive chunks
+// going to consecutive workers. Lines without a usable key go to worker 0,
+// which rejects them.
+func partitionOf(line, sep string, n int) int {
+	switch partitionBy {
+	case partitionUniqueID:
+		fields, err := profile.transform(line, sep)
+		if err != nil {
+			return 0
+		}
+		h := fnv.New32a()
+		h.Write([]byte(fields[1]))
+		// The low bits of FNV vary little across similar keys, so scale by
+		// the high bits rather than taking the remainder
+		return int(uint64(h.Sum32()) * uint64(n) >> 32)
+	case partitionTime:
+		field := line
+		if i := strings.Index(line, sep); i >= 0 {
+			field = line[:i]
+		}
+		t, err := parseTimestamp(field)
+		if err != nil {
+			return 0
+		}
+		chunk := t.Unix() / int64(partitionChunk.Seconds())
+		return int(((chunk % int64(n)) + int64(n)) % int64(n))
+	}
+	return 0
+}
+
+// lineNo returns the input line number of row i of a batch.
+func (b *batch) lineNo(i int) int64 {
+	if b.lines != nil {
+		return b.lines[i]
+	}
+	return b.firstLine + int64(i)
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Phases --statement-timeout can limit
+const (
+	phaseSetup    = "setup"     // truncate and replace-range
+	phaseCopy     = "copy"      // COPY batches
+	phasePostLoad = "post-load" // each post-load stage
+	phaseExport   = "export"    // export and export-parquet queries
+)
+
+// healthCheckTimeout bounds how long the health check waits for the server.
+const healthCheckTimeout = 10 * time.Second
+
+// statementTimeouts holds the parsed --statement-timeout per phase.
+var statementTimeouts map[string]time.Duration
+
+// getConnectString adds --db-name to --connection, the primary target.
+func getConnectString() string {
+	return connectString(connKeywords(postgresConnect) + " dbname=" + connValue(dbName))
+}
+
+// connKeywords turns a postgres:// URL into key=value form so more keys can
+// be appended to it.
+func connKeywords(conn string) string {
+	if !strings.HasPrefix(conn, "postgres://") && !strings.HasPrefix(conn, "postgresql://") {
+		return conn
+	}
+	kv, err := pq.ParseURL(conn)
+	if err != nil {
+		log.Fatalf("Invalid connection URL: %s", err.Error())
+	}
+	return kv
+}
+
+// connectString adds the application_name and TLS flags to a target's
+// connection string. Later keys override earlier ones, so the flags win over
+// the same settings in the connection string.
+func connectString(base string) string {
+	params := []string{base}
+	if pgbouncerMode {
+		// Send parse, bind and execute in one round trip, so a query with
+		// parameters never leaves an unnamed statement on a server connection
+		// PgBouncer may hand to another client in between
+		params = append(params, "binary_parameters=yes")
+	}
+	for _, p := range [][2]string{
+		{"application_name", applicationName},
+		{"sslmode", sslMode},
+		{"sslrootcert", sslRootCert},
+		{"sslcert", sslCert},
+		{"sslkey", sslKey},
+	} {
+		if p[1] != "" {
+			params = append(params, p[0]+"="+connValue(p[1]))
+		}
+	}
+	return strings.Join(params, " ")
+}
+
+// connValue quotes a value for a key=value connection string when needed.
+func connValue(v string) string {
+	if v != "" && !strings.ContainsAny(v, ` '\`) {
+		return v
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
+
+// connect returns the connection pool of the current target, which every
+// phase of the run against it shares.
+func connect() *sqlx.DB {
+	if current == nil {
+		log.Fatalf("No database to connect to, --sink %s writes to %s", sinkName, sinkPath)
+	}
+	return current.db()
+}
+
+// db opens the target's pool on first use. It holds at most --max-conns
+// connections, by default one per worker plus one for the statements run
+// outside the workers.
+func (t *loadTarget) db() *sqlx.DB {
+	t.poolOnce.Do(func() {
+		var err error
+		if t.pool, err = sqlx.Open("postgres", t.connect); err != nil {
+			log.Fatal(err)
+		}
+		max := maxConns
+		if max <= 0 {
+			max = workers + 1
+		}
+		t.pool.SetMaxOpenConns(max)
+		t.pool.SetMaxIdleConns(max)
+	})
+	return t.pool
+}
+
+// closeTargets closes the pools of the database targets and the files of a
+// local one.
+func closeTargets() {
+	for _, t := range targets {
+		if t.pool != nil {
+			t.pool.Close()
+		}
+		if t.close != nil {
+			t.close()
+		}
+	}
+}
+
+// parseStatementTimeouts parses --statement-timeout, a comma-separated list of
+// phase=duration.
+func parseStatementTimeouts() {
+	statementTimeouts = make(map[string]time.Duration)
+	if statementTimeout == "" {
+		return
+	}
+	for _, item := range strings.Split(statementTimeout, ",") {
+		kv := strings.SplitN(strings.TrimSpace(item), "=", 2)
+		if len(kv) != 2 {
+			log.Fatalf("Invalid --statement-timeout %q, expected phase=duration", item)
+		}
+		switch kv[0] {
+		case phaseSetup, phaseCopy, phasePostLoad, phaseExport:
+		default:
+			log.Fatalf("Unknown --statement-timeout phase %q, expected %s, %s, %s or %s", kv[0], phaseSetup, phaseCopy, phasePostLoad, phaseExport)
+		}
+		d, err := time.ParseDuration(kv[1])
+		if err != nil || d < 0 {
+			log.Fatalf("Invalid --statement-timeout for %s: %q", kv[0], kv[1])
+		}
+		statementTimeouts[kv[0]] = d
+	}
+}
+
+// setLocalTimeout applies the phase's statement timeout to a transaction.
+func setLocalTimeout(ctx context.Context, tx *sqlx.Tx, phase string) error {
+	d, ok := statementTimeouts[phase]
+	if !ok {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", d.Milliseconds()))
+	return err
+}
+
+// phaseConn takes a connection from the pool for statements that cannot run in
+// a transaction, with the phase's statement timeout set. Run them with the
+// returned context and hand the connection back with release.
+//
+// With --pgbouncer a SET would stay on whatever server connection PgBouncer
+// picked, so the timeout is a context deadline instead; lib/pq cancels the
+// statement when it passes.
+func phaseConn(phase string) (conn *sqlx.Conn, ctx context.Context, release func()) {
+	ctx = context.Background()
+	conn, err := connect().Connx(ctx)
+	if err != nil {
+		panic(err)
+	}
+	d, ok := statementTimeouts[phase]
+	if !ok {
+		return conn, ctx, func() { conn.Close() }
+	}
+	if pgbouncerMode {
+		ctx, cancel := context.WithTimeout(ctx, d)
+		return conn, ctx, func() {
+			cancel()
+			conn.Close()
+		}
+	}
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("SET statement_timeout = %d", d.Milliseconds())); err != nil {
+		panic(err)
+	}
+	return conn, ctx, func() {
+		conn.ExecContext(context.Background(), "RESET statement_timeout")
+		conn.Close()
+	}
+}
+
+// healthCheck makes sure the server answers and the run's table exists and
+// grants the privilege it needs, so a run fails before any work is done.
+func healthCheck(table, privilege string) {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+
+	db := connect()
+	if err := db.PingContext(ctx); err != nil {
+		log.Fatalf("Health check: cannot reach %s: %s", current.name, err.Error())
+	}
+	var r struct {
+		User    string `db:"usr"`
+		Version string `db:"version"`
+		Exists  bool   `db:"table_exists"`
+		Granted bool   `db:"granted"`
+	}
+	err := db.GetContext(ctx, &r, `SELECT current_user AS usr, current_setting('server_version') AS version,
+		to_regclass($1) IS NOT NULL AS table_exists,
+		to_regclass($1) IS NOT NULL AND has_table_privilege(to_regclass($1), $2) AS granted`, table, privilege)
+	if err != nil {
+		log.Fatalf("Health check of %s: %s", current.name, err.Error())
+	}
+	if !r.Exists {
+		log.Fatalf("Health check of %s: %s does not exist", current.name, table)
+	}
+	if !r.Granted {
+		log.Fatalf("Health check of %s: %s has no %s privilege on %s", current.name, r.User, privilege, table)
+	}
+	if verbose {
+		fmt.Printf("Connected to %s as %s, PostgreSQL %s, pool of up to %d connections\n", current.name, r.User, r.Version, db.Stats().MaxOpenConnections)
+	}
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// stage is a named SQL statement run after the COPY has finished. The SQL is a
+// text/template rendered with stageParams. Stages with an enabled func only run
+// by default when it returns true.
+type stage struct {
+	name    string
+	sql     string
+	enabled func() bool
+}
+
+// stageParams holds the values available to stage templates. Table names are
+// already schema-qualified and quoted.
+type stageParams struct {
+	Schema     string
+	Staging    string
+	Hourly     string
+	Daily      string
+	TimeColumn string
+	From       string
+	To         string
+	// Keys lists the key columns after the time column; NE and CellID are the
+	// network element and cell id columns of the --profile technology
+	Keys   string
+	NE     string
+	CellID string
+	// DailyCounters and DailySums list the rolled up counters and their sum()
+	DailyCounters string
+	DailySums     string
+	// DailySource is what the daily stage rolls up: the staging table, or with
+	// --reload-range the hourly rows of the reloaded days, since
+	// delete-daily-range removed those days whole
+	DailySource string
+
+	KPIHourly      string
+	KPIDaily       string
+	KPIHourlyDDL   string
+	KPIDailyDDL    string
+	KPINames       string
+	KPIHourlyExprs string
+	KPIDailyExprs  string
+	KPIUpdates     string
+
+	RunID          string
+	DQTable        string
+	DQTableDDL     string
+	DQSuccessPairs string
+	DQCellDrop     string
+	// DQCounterValues unpivots the numeric counters of a staging row t
+	DQCounterValues string
+
+	Cells    string
+	CellsDDL string
+	// LoadedCells selects the latest name of every (NE, CellID) in the staging
+	// table with the first and last time it was seen under that name.
+	LoadedCells string
+}
+
+// Values of --daily-mode
+const (
+	dailyModeInsert     = "insert"
+	dailyModeContinuous = "continuous"
+)
+
+// postLoadStages are run in order once every batch has been committed.
+var postLoadStages = []stage{
+	{"delete-hourly-range", `DELETE FROM {{.Hourly}} WHERE {{.TimeColumn}} BETWEEN '{{.From}}' AND '{{.To}}'`, reloadRangeEnabled},
+	{"delete-daily-range", `DELETE FROM {{.Daily}} WHERE tanggal BETWEEN date_trunc('day', '{{.From}}'::timestamp) AND '{{.To}}'`, reloadDailyEnabled},
+	{"hourly", `insert into {{.Hourly}} select * from {{.Staging}} on conflict do nothing`, nil},
+	{"daily", `insert into {{.Daily}} (tanggal, {{.Keys}}, {{.DailyCounters}}) select time_bucket('1 day',{{.TimeColumn}}) tanggal, {{.Keys}},{{.DailySums}}
+	from {{.DailySource}} group by tanggal, {{.Keys}} on conflict do nothing`, dailyInsertEnabled},
+	{"refresh-daily", `CALL refresh_continuous_aggregate('{{.Daily}}', date_trunc('day', '{{.From}}'::timestamp), date_trunc('day', '{{.To}}'::timestamp) + INTERVAL '1 day')`, continuousDailyEnabled},
+	{"kpi-hourly", `{{.KPIHourlyDDL}}
+	INSERT INTO {{.KPIHourly}} ({{.TimeColumn}}, {{.Keys}}, {{.KPINames}})
+	SELECT {{.TimeColumn}}, {{.Keys}}, {{.KPIHourlyExprs}} FROM {{.Hourly}}
+	WHERE {{.TimeColumn}} BETWEEN '{{.From}}' AND '{{.To}}'
+	ON CONFLICT ({{.TimeColumn}}, unique_id) DO UPDATE SET {{.KPIUpdates}}`, kpiEnabled},
+	{"kpi-daily", `{{.KPIDailyDDL}}
+	INSERT INTO {{.KPIDaily}} (tanggal, {{.Keys}}, {{.KPINames}})
+	SELECT tanggal, {{.Keys}}, {{.KPIDailyExprs}} FROM {{.Daily}}
+	WHERE tanggal BETWEEN date_trunc('day', '{{.From}}'::timestamp) AND '{{.To}}'
+	ON CONFLICT (tanggal, unique_id) DO UPDATE SET {{.KPIUpdates}}`, kpiEnabled},
+	{"dq-missing-hours", `{{.DQTableDDL}}
+	INSERT INTO {{.DQTable}} (run_id, check_name, resulttime, unique_id, value, detail)
+	SELECT '{{.RunID}}', 'missing_hours', date_trunc('day', h), c.unique_id, count(*), string_agg(to_char(h, 'HH24'), ',' ORDER BY h)
+	FROM (SELECT DISTINCT unique_id FROM {{.Staging}}) c
+	CROSS JOIN generate_series(date_trunc('day', '{{.From}}'::timestamp),
+		LEAST(date_trunc('day', '{{.To}}'::timestamp) + INTERVAL '23 hours', GREATEST('{{.To}}'::timestamp, localtimestamp - INTERVAL '1 hour')),
+		INTERVAL '1 hour') h
+	WHERE NOT EXISTS (SELECT 1 FROM {{.Hourly}} x WHERE x.{{.TimeColumn}} = h AND x.unique_id = c.unique_id)
+	GROUP BY date_trunc('day', h), c.unique_id`, dqEnabled},
+	{"dq-duplicates", `{{.DQTableDDL}}
+	INSERT INTO {{.DQTable}} (run_id, check_name, resulttime, unique_id, value)
+	SELECT '{{.RunID}}', 'duplicate_rows', {{.TimeColumn}}, unique_id, count(*) FROM {{.Staging}}
+	GROUP BY {{.TimeColumn}}, unique_id HAVING count(*) > 1`, dqEnabled},
+	{"dq-negative-counters", `{{.DQTableDDL}}
+	INSERT INTO {{.DQTable}} (run_id, check_name, resulttime, unique_id, value, detail)
+	SELECT '{{.RunID}}', 'negative_counter', t.{{.TimeColumn}}, t.unique_id, kv.value, kv.key
+	FROM {{.Staging}} t CROSS JOIN LATERAL (VALUES {{.DQCounterValues}}) kv(key, value)
+	WHERE kv.value < 0`, dqEnabled},
+	{"dq-success-gt-attempt", `{{.DQTableDDL}}
+	INSERT INTO {{.DQTable}} (run_id, check_name, resulttime, unique_id, value, detail)
+	SELECT '{{.RunID}}', 'success_gt_attempt', t.{{.TimeColumn}}, t.unique_id, p.succ - p.att, format('%s=%s > %s=%s', p.succ_name, p.succ, p.att_name, p.att)
+	FROM {{.Staging}} t CROSS JOIN LATERAL (VALUES {{.DQSuccessPairs}}) p(succ_name, att_name, succ, att)
+	WHERE p.succ > p.att`, dqEnabled},
+	{"dq-cell-count-drop", `{{.DQTableDDL}}
+	WITH days AS (
+		SELECT date_trunc('day', {{.TimeColumn}}) AS d, count(DISTINCT unique_id) AS n FROM {{.Hourly}}
+		WHERE {{.TimeColumn}} >= date_trunc('day', '{{.From}}'::timestamp) - INTERVAL '1 day' AND {{.TimeColumn}} <= '{{.To}}'
+		GROUP BY 1
+	)
+	INSERT INTO {{.DQTable}} (run_id, check_name, resulttime, value, detail)
+	SELECT '{{.RunID}}', 'cell_count_drop', cur.d, cur.n - prev.n, format('%s cells, %s the day before', cur.n, prev.n)
+	FROM days cur JOIN days prev ON prev.d = cur.d - INTERVAL '1 day'
+	WHERE cur.d >= date_trunc('day', '{{.From}}'::timestamp) AND cur.n < prev.n * (1 - {{.DQCellDrop}} / 100.0)`, dqEnabled},
+	{cellsStage, `{{.CellsDDL}}
+	UPDATE {{.Cells}} d SET is_current = false, closed_run = '{{.RunID}}'
+	FROM ({{.LoadedCells}}) s
+	WHERE d.is_current AND d.{{.NE}} = s.{{.NE}} AND d.{{.CellID}} = s.{{.CellID}} AND d.cellname IS DISTINCT FROM s.cellname AND d.last_seen < s.last_seen;
+	UPDATE {{.Cells}} d SET first_seen = least(d.first_seen, s.first_seen), last_seen = greatest(d.last_seen, s.last_seen)
+	FROM ({{.LoadedCells}}) s
+	WHERE d.is_current AND d.{{.NE}} = s.{{.NE}} AND d.{{.CellID}} = s.{{.CellID}} AND d.cellname IS NOT DISTINCT FROM s.cellname;
+	INSERT INTO {{.Cells}} ({{.Keys}}, first_seen, last_seen, opened_run)
+	SELECT {{.Keys}}, s.first_seen, s.last_seen, '{{.RunID}}'
+	FROM ({{.LoadedCells}}) s
+	WHERE NOT EXISTS (SELECT 1 FROM {{.Cells}} d WHERE d.is_current AND d.{{.NE}} = s.{{.NE}} AND d.{{.CellID}} = s.{{.CellID}})`, cellsEnabled},
+	{"truncate-staging", `TRUNCATE {{.Staging}}`, nil},
+}
+
+func cellsEnabled() bool {
+	return cellsMode
+}
+
+func dqEnabled() bool {
+	return dqMode
+}
+
+func kpiEnabled() bool {
+	return kpiMode || len(kpiFile) > 0
+}
+
+func reloadRangeEnabled() bool {
+	return reloadRange
+}
+
+// A continuous aggregate rebuilds the whole refreshed window, so it needs
+// neither the daily insert nor the delete before a reload.
+func reloadDailyEnabled() bool {
+	return reloadRange && dailyMode == dailyModeInsert
+}
+
+func dailyInsertEnabled() bool {
+	return dailyMode == dailyModeInsert
+}
+
+func continuousDailyEnabled() bool {
+	return dailyMode == dailyModeContinuous
+}
+
+func quoteTable(schema, table string) string {
+	return fmt.Sprintf("\"%s\".\"%s\"", schema, table)
+}
+
+// selectedStages returns the stages to run, honouring --only-stage. Stages
+// always run in pipeline order, whatever order --only-stage lists them in.
+func selectedStages() []stage {
+	if onlyStage == "" {
+		var selected []stage
+		for _, s := range postLoadStages {
+			if s.enabled == nil || s.enabled() {
+				selected = append(selected, s)
+			}
+		}
+		return selected
+	}
+
+	chosen := make(map[string]bool)
+	for _, name := range strings.Split(onlyStage, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		prefix := strings.TrimSuffix(name, "*")
+		for _, s := range postLoadStages {
+			// A trailing * selects every stage with that prefix, e.g. dq-*
+			if s.name == name || prefix != name && strings.HasPrefix(s.name, prefix) {
+				if chosen[s.name] {
+					log.Fatalf("Stage %s is selected twice by --only-stage", s.name)
+				}
+				chosen[s.name] = true
+				found = true
+			}
+		}
+		if !found {
+			log.Fatalf("Unknown stage %q for --only-stage", name)
+		}
+	}
+
+	var selected []stage
+	for _, s := range postLoadStages {
+		if chosen[s.name] {
+			selected = append(selected, s)
+		}
+	}
+	return selected
+}
+
+// loadedRange returns the min and max of the time column in the staging table.
+// ok is false when the staging table is empty.
+func loadedRange(db *sqlx.DB) (from, to time.Time, ok bool) {
+	var r struct {
+		From *time.Time `db:"from_time"`
+		To   *time.Time `db:"to_time"`
+	}
+	q := fmt.Sprintf("SELECT min(%[1]s) AS from_time, max(%[1]s) AS to_time FROM %[2]s", timeColumn, getFullTableName())
+	if err := db.Get(&r, q); err != nil {
+		panic(err)
+	}
+	if r.From == nil || r.To == nil {
+		return time.Time{}, time.Time{}, false
+	}
+	return *r.From, *r.To, true
+}
+
+func newStageParams(from, to time.Time, counters []column) stageParams {
+	names := make([]string, len(counters))
+	for i, c := range counters {
+		names[i] = c.Name
+	}
+	params := stageParams{
+		Schema:         schemaName,
+		Staging:        getFullTableName(),
+		Hourly:         quoteTable(schemaName, hourlyTable),
+		Daily:          quoteTable(schemaName, dailyTable),
+		TimeColumn:     timeColumn,
+		Keys:           tech.keyList(),
+		NE:             tech.ne(),
+		CellID:         tech.cellID(),
+		From:           from.Format(time.RFC3339),
+		To:             to.Format(time.RFC3339),
+		DailyCounters:  strings.Join(names, ","),
+		DailySums:      rollupSums(counters),
+		KPIHourly:      quoteTable(schemaName, kpiHourlyTable),
+		KPIDaily:       quoteTable(schemaName, kpiDailyTable),
+		RunID:          runID,
+		DQTable:        quoteTable(schemaName, dqTable),
+		DQSuccessPairs: successPairValues(counters),
+		DQCellDrop:     strconv.FormatFloat(dqCellDrop, 'f', -1, 64),
+	}
+	params.DQTableDDL = dqTableDDL(params.DQTable)
+	params.DQCounterValues = counterValues(counters)
+	params.DailySource = params.Staging
+	if reloadDailyEnabled() {
+		params.DailySource = fmt.Sprintf(`(SELECT * FROM %[1]s WHERE %[2]s >= date_trunc('day', '%[3]s'::timestamp)
+		AND %[2]s < date_trunc('day', '%[4]s'::timestamp) + INTERVAL '1 day') h`, params.Hourly, timeColumn, params.From, params.To)
+	}
+	params.Cells = quoteTable(schemaName, cellTable)
+	params.CellsDDL = cellTableDDL(params.Cells)
+	params.LoadedCells = fmt.Sprintf(`SELECT DISTINCT ON (%[3]s, %[4]s) %[5]s, first_seen, last_seen FROM (
+		SELECT %[5]s, min(%[1]s) AS first_seen, max(%[1]s) AS last_seen FROM %[2]s GROUP BY %[5]s
+	) g ORDER BY %[3]s, %[4]s, last_seen DESC`, timeColumn, params.Staging, params.NE, params.CellID, params.Keys)
+	if kpiEnabled() {
+		kpis := loadKPIs()
+		params.KPIHourlyDDL = kpiTableDDL(params.KPIHourly, timeColumn, kpis)
+		params.KPIDailyDDL = kpiTableDDL(params.KPIDaily, "tanggal", kpis)
+		params.KPINames, params.KPIHourlyExprs, params.KPIUpdates = kpiColumns(kpis, 3600)
+		_, params.KPIDailyExprs, _ = kpiColumns(kpis, 86400)
+	}
+	return params
+}
+
+func renderStage(s stage, params stageParams) string {
+	tmpl, err := template.New(s.name).Parse(s.sql)
+	if err != nil {
+		log.Fatalf("Invalid SQL template for stage %s: %s", s.name, err.Error())
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, params); err != nil {
+		log.Fatalf("Cannot render stage %s: %s", s.name, err.Error())
+	}
+	return buf.String()
+}
+
+// runPostLoad executes the selected post-load stages against the rows that were
+// just copied into the staging table, timing and reporting each one.
+func runPostLoad(stages []stage) {
+	db := connect()
+
+	from, to, ok := loadedRange(db)
+	if !ok {
+		fmt.Println("Staging table is empty, skipping post-load stages")
+		return
+	}
+
+	params := newStageParams(from, to, rollupCounters(db))
+	start := time.Now()
+	ranDQ := false
+	for _, s := range stages {
+		if abortCtx.Err() != nil {
+			fmt.Printf("Grace period over, skipping stage %s and the ones after it\n", s.name)
+			break
+		}
+		stageStart := time.Now()
+		conn, ctx, release := phaseConn(phasePostLoad)
+		res, err := conn.ExecContext(ctx, renderStage(s, params))
+		release()
+		if err != nil {
+			panic(err)
+		}
+		affected, _ := res.RowsAffected()
+		fmt.Printf("[STAGE] %s took %v, %d rows\n", s.name, time.Now().Sub(stageStart), affected)
+		ranDQ = ranDQ || isDQStage(s)
+		if s.name == cellsStage {
+			reportCells(db, params)
+		}
+	}
+	if ranDQ {
+		reportFindings(db)
+	}
+	fmt.Printf("Post-load for %s to %s finished in %v\n", params.From, params.To, time.Now().Sub(start))
+}
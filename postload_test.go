@@ -0,0 +1,74 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func stageNames(stages []stage) []string {
+	names := make([]string, len(stages))
+	for i, s := range stages {
+		names[i] = s.name
+	}
+	return names
+}
+
+func TestSelectedStagesPipelineOrder(t *testing.T) {
+	tests := []struct {
+		only string
+		want []string
+	}{
+		{"truncate-staging,daily,hourly", []string{"hourly", "daily", "truncate-staging"}},
+		{"cells, dq-*", []string{"dq-missing-hours", "dq-duplicates", "dq-negative-counters", "dq-success-gt-attempt", "dq-cell-count-drop", "cells"}},
+	}
+	for _, tt := range tests {
+		setFlag(t, "only-stage", tt.only)
+		if got := stageNames(selectedStages()); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("--only-stage %s: got %v, want %v", tt.only, got, tt.want)
+		}
+	}
+}
+
+// TestMissingHoursStopsAtLastCompletedHour checks that an hourly load of today
+// is not reported for the hours still to come.
+func TestMissingHoursStopsAtLastCompletedHour(t *testing.T) {
+	var missing stage
+	for _, s := range postLoadStages {
+		if s.name == "dq-missing-hours" {
+			missing = s
+		}
+	}
+	from := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	sql := renderStage(missing, newStageParams(from, from, nil))
+	want := `LEAST(date_trunc('day', '2024-03-01T10:00:00Z'::timestamp) + INTERVAL '23 hours', GREATEST('2024-03-01T10:00:00Z'::timestamp, localtimestamp - INTERVAL '1 hour'))`
+	if !strings.Contains(sql, want) {
+		t.Errorf("missing hours are not bounded by the last completed hour:\n%s", sql)
+	}
+}
+
+func TestDailyStageSource(t *testing.T) {
+	var daily stage
+	for _, s := range postLoadStages {
+		if s.name == "daily" {
+			daily = s
+		}
+	}
+	from := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	sql := renderStage(daily, newStageParams(from, to, nil))
+	if !strings.Contains(sql, `from "public"."test_table" group by`) {
+		t.Errorf("daily stage does not roll up the staging table:\n%s", sql)
+	}
+
+	// A partial day reload rebuilds the whole day from the hourly table
+	setFlag(t, "reload-range", "true")
+	sql = renderStage(daily, newStageParams(from, to, nil))
+	want := `(SELECT * FROM "public"."counter_3g_hourly" WHERE resulttime >= date_trunc('day', '2024-03-01T10:00:00Z'::timestamp)
+		AND resulttime < date_trunc('day', '2024-03-01T12:00:00Z'::timestamp) + INTERVAL '1 day') h`
+	if !strings.Contains(sql, want) {
+		t.Errorf("daily stage with --reload-range does not roll up the hourly rows of the day:\n%s", sql)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// pgTimestampLayout formats times as literals without a zone, so they compare
+// the same way against timestamp and timestamptz columns.
+const pgTimestampLayout = "2006-01-02 15:04:05.999999"
+
+// inputRange returns the half-open [from, to) range of resulttime values in the
+// input. With --file it pre-scans the file; reading stdin it can only use
+// --from and --to.
+func inputRange() (from, to time.Time) {
+	if len(fromFile) == 0 {
+		if fromTime.IsZero() || toTime.IsZero() {
+			log.Fatal("--replace-range needs --file to pre-scan, or both --from and --to when reading stdin")
+		}
+		return fromTime, toTime
+	}
+
+	scanner, _, closeInput := openInput()
+	defer closeInput()
+
+	sChar := splitSeparator()
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !inTimeRange(line, sChar) {
+			continue
+		}
+		field := line
+		if i := strings.Index(line, sChar); i >= 0 {
+			field = line[:i]
+		}
+		t, err := parseTimestamp(field)
+		if err != nil {
+			continue
+		}
+		if from.IsZero() || t.Before(from) {
+			from = t
+		}
+		if t.After(to) {
+			to = t
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatalf("Error pre-scanning input: %s", err.Error())
+	}
+
+	// Timestamps have microsecond resolution, so this makes max inclusive
+	return from, to.Add(time.Microsecond)
+}
+
+func isHypertable(db *sqlx.DB) bool {
+	var n int
+	err := db.Get(&n, `SELECT count(*) FROM timescaledb_information.hypertables
+		WHERE hypertable_schema = $1 AND hypertable_name = $2`, schemaName, tableName)
+	if err != nil {
+		// timescaledb_information is missing when the extension is not installed
+		return false
+	}
+	return n > 0
+}
+
+// replaceRange removes the rows of the destination table that the input is
+// about to replace. On a hypertable the chunks that lie entirely inside the
+// range are dropped and only the boundary chunks are deleted from.
+func replaceRange() {
+	from, to := inputRange()
+	if from.IsZero() {
+		fmt.Println("No timestamps in input, nothing to replace")
+		return
+	}
+
+	db := connect()
+
+	var colType string
+	err := db.Get(&colType, `SELECT format_type(atttypid, atttypmod) FROM pg_attribute
+		WHERE attrelid = $1::regclass AND attname = $2`, getFullTableName(), strings.ToLower(timeColumn))
+	if err != nil {
+		panic(err)
+	}
+
+	fromStr, toStr := from.Format(pgTimestampLayout), to.Format(pgTimestampLayout)
+	hypertable := isHypertable(db)
+	start := time.Now()
+	tx := db.MustBegin()
+	if err = setLocalTimeout(context.Background(), tx, phaseSetup); err != nil {
+		tx.Rollback()
+		panic(err)
+	}
+
+	chunks := 0
+	if hypertable {
+		var dropped []string
+		err = tx.Select(&dropped, fmt.Sprintf("SELECT drop_chunks($1::regclass, older_than => $2::%[1]s, newer_than => $3::%[1]s)", colType),
+			getFullTableName(), toStr, fromStr)
+		if err != nil {
+			tx.Rollback()
+			panic(err)
+		}
+		chunks = len(dropped)
+	}
+
+	res, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s >= $1 AND %s < $2", getFullTableName(), timeColumn, timeColumn), fromStr, toStr)
+	if err != nil {
+		tx.Rollback()
+		panic(err)
+	}
+	if err = tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	deleted, _ := res.RowsAffected()
+	fmt.Printf("Replaced range %s to %s: %d chunks dropped, %d rows deleted in %v\n", fromStr, toStr, chunks, deleted, time.Now().Sub(start))
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// loadCounters returns the counter catalogue, read from --catalogue when given.
+// The file uses the same column_name,data_type lines as --schema-file.
+func loadCounters() []column {
+	if len(catalogueFile) > 0 {
+		return readSchemaFile(catalogueFile)
+	}
+	return tech.counters
+}
+
+// rollupSums builds the sum() list of the daily rollup, one per counter.
+func rollupSums(counters []column) string {
+	sums := make([]string, len(counters))
+	for i, c := range counters {
+		sums[i] = fmt.Sprintf("sum(%s)", c.Name)
+	}
+	return strings.Join(sums, ",")
+}
+
+func columnDefs(cols []column) string {
+	defs := make([]string, len(cols))
+	for i, c := range cols {
+		defs[i] = fmt.Sprintf("\t%s %s", c.Name, c.Type)
+	}
+	return strings.Join(defs, ",\n")
+}
+
+func createTable(table string, cols []column, primaryKey string) string {
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n%s,\n\tPRIMARY KEY (%s)\n)", table, columnDefs(cols), primaryKey)
+}
+
+func createHypertable(table, timeCol, interval string) string {
+	return fmt.Sprintf("SELECT create_hypertable('%s', '%s', chunk_time_interval => INTERVAL '%s', if_not_exists => TRUE)", table, timeCol, interval)
+}
+
+// createContinuousAggregate defines the daily table as a continuous aggregate
+// over the hourly hypertable, summing the same counters as the daily insert.
+func createContinuousAggregate(daily, hourly string, counters []column) string {
+	sums := make([]string, len(counters))
+	for i, c := range counters {
+		sums[i] = fmt.Sprintf("\tsum(%[1]s) AS %[1]s", c.Name)
+	}
+	return fmt.Sprintf(`CREATE MATERIALIZED VIEW IF NOT EXISTS %[1]s WITH (timescaledb.continuous) AS
+SELECT time_bucket('1 day', %[2]s) AS tanggal, %[3]s,
+%[4]s
+FROM %[5]s
+GROUP BY 1, %[3]s
+WITH NO DATA`, daily, timeColumn, tech.keyList(), strings.Join(sums, ",\n"), hourly)
+}
+
+func compressionPolicy(table string) []string {
+	return []string{
+		fmt.Sprintf("ALTER TABLE %s SET (timescaledb.compress, timescaledb.compress_segmentby = 'unique_id')", table),
+		fmt.Sprintf("SELECT add_compression_policy('%s', INTERVAL '%s', if_not_exists => TRUE)", table, compressAfter),
+	}
+}
+
+// schemaDDL generates the statements creating the staging, hourly and daily
+// tables from the counter catalogue.
+func schemaDDL() []string {
+	counters := loadCounters()
+	keys := make([]column, len(tech.keys))
+	copy(keys, tech.keys)
+	keys[0].Name = timeColumn
+
+	hourlyCols := append(keys, counters...)
+	dailyCols := append([]column{{"tanggal", keys[0].Type}}, keys[1:]...)
+	dailyCols = append(dailyCols, counters...)
+
+	staging := getFullTableName()
+	hourly := quoteTable(schemaName, hourlyTable)
+	daily := quoteTable(schemaName, dailyTable)
+	hourlyKey := timeColumn + ", unique_id"
+
+	ddl := []string{
+		"CREATE EXTENSION IF NOT EXISTS timescaledb",
+		fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS \"%s\"", schemaName),
+		createTable(staging, hourlyCols, hourlyKey),
+		createTable(hourly, hourlyCols, hourlyKey),
+		createHypertable(hourly, timeColumn, chunkInterval),
+	}
+	if len(compressAfter) > 0 {
+		ddl = append(ddl, compressionPolicy(hourly)...)
+	}
+
+	if dailyMode == dailyModeContinuous {
+		return append(ddl, createContinuousAggregate(daily, hourly, counters))
+	}
+	ddl = append(ddl,
+		createTable(daily, dailyCols, "tanggal, unique_id"),
+		createHypertable(daily, "tanggal", dailyChunkInterval),
+	)
+	if len(compressAfter) > 0 {
+		ddl = append(ddl, compressionPolicy(daily)...)
+	}
+	return ddl
+}
+
+// initSchema creates the tables the importer loads into. With --dry-run the
+// DDL is only printed.
+func initSchema() {
+	ddl := schemaDDL()
+	if dryRunMode {
+		for _, stmt := range ddl {
+			fmt.Println(stmt + ";")
+		}
+		return
+	}
+
+	db := connect()
+
+	start := time.Now()
+	for _, stmt := range ddl {
+		db.MustExec(stmt)
+	}
+	fmt.Printf("Created %s, %s and %s with %d counters in %v\n", getFullTableName(), quoteTable(schemaName, hourlyTable),
+		quoteTable(schemaName, dailyTable), len(loadCounters()), time.Now().Sub(start))
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+var (
+	// stopCtx is cancelled on the first SIGINT or SIGTERM: no more input is
+	// read and queued batches are dropped
+	stopCtx = context.Background()
+	// abortCtx is cancelled --grace-period later: batches still in flight
+	// are rolled back and no further post-load stage starts
+	abortCtx = context.Background()
+
+	// dispatched are the batches scan handed to the workers, in input order.
+	// Their rows are released once every target is done with them.
+	dispatched []*batch
+	// nextLine is the input line number scan would have read next
+	nextLine int64
+	// inputDone is set when scan has read the input to its end
+	inputDone bool
+)
+
+// handleSignals installs the SIGINT/SIGTERM handler. A second signal after the
+// first one kills the process immediately.
+func handleSignals() {
+	var stop context.CancelFunc
+	stopCtx, stop = signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	var abort context.CancelFunc
+	abortCtx, abort = context.WithCancel(context.Background())
+
+	go func() {
+		<-stopCtx.Done()
+		stop() // restore the default handlers
+		fmt.Fprintf(os.Stderr, "Interrupted, finishing in-flight batches for up to %v; interrupt again to exit now\n", gracePeriod)
+		time.AfterFunc(gracePeriod, abort)
+	}()
+}
+
+func interrupted() bool {
+	return stopCtx.Err() != nil
+}
+
+// resumeLine returns the first input line not known to be committed to a
+// target: the earliest first line of a batch uncommitted there, or the line
+// after the last one read. Later batches may have been committed too.
+func resumeLine(target int) (line int64, committedAfter int) {
+	line = nextLine
+	for _, b := range dispatched {
+		if atomic.LoadInt32(&b.committed[target]) == 0 && b.firstLine < line {
+			line = b.firstLine
+		}
+	}
+	for _, b := range dispatched {
+		if atomic.LoadInt32(&b.committed[target]) == 1 && b.lastLine >= line {
+			committedAfter++
+		}
+	}
+	return line, committedAfter
+}
+
+// release is called by the worker of each target once it is done with the
+// batch, committed or not. The last one drops the rows, so dispatched does not
+// hold the whole input.
+func (b *batch) release() {
+	if atomic.AddInt32(&b.pending, -1) == 0 {
+		b.rows, b.lines = nil, nil
+	}
+}
+
+// allCommitted reports whether the whole input was read and every batch of it
+// committed to a target.
+func allCommitted(target int) bool {
+	if !inputDone {
+		return false
+	}
+	for _, b := range dispatched {
+		if atomic.LoadInt32(&b.committed[target]) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// printResume prints where an interrupted or failed load into a target can be
+// picked up again.
+func printResume(t *loadTarget) {
+	line, committedAfter := resumeLine(t.index)
+	skip := line - 1
+	if hasHeader {
+		skip--
+	}
+	if fanOut() {
+		fmt.Printf("%s: input committed up to line %d; resume it with --skip-lines %d\n", t.name, line-1, skip)
+	} else {
+		fmt.Printf("Interrupted: input committed up to line %d; resume with --skip-lines %d\n", line-1, skip)
+	}
+	if committedAfter > 0 {
+		fmt.Printf("%d later batch(es) were committed as well; their rows will be loaded again on resume\n", committedAfter)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// sink is where a worker writes its batches: the destination table through
+// COPY, or a message stream. Each worker opens its own sink and writes every
+// batch between Begin and Commit; the rows of a batch that is rolled back are
+// not kept.
+type sink interface {
+	Begin(ctx context.Context) error
+	WriteRows(rows [][]string) error
+	Commit() error
+	Rollback() error
+	Close() error
+}
+
+// pgSink copies each batch into the destination table in a transaction of
+// its own.
+type pgSink struct {
+	db   *sqlx.DB
+	ctx  context.Context
+	tx   *sqlx.Tx
+	stmt *sql.Stmt
+}
+
+func (s *pgSink) Begin(ctx context.Context) error {
+	s.ctx, s.tx, s.stmt = ctx, nil, nil
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	s.tx = tx
+	if err := setLocalTimeout(ctx, tx, phaseCopy); err != nil {
+		return err
+	}
+	s.stmt, err = tx.PrepareContext(ctx, copyCommand())
+	return err
+}
+
+func (s *pgSink) WriteRows(rows [][]string) error {
+	for _, fields := range rows {
+		if _, err := s.stmt.ExecContext(s.ctx, copyArgs(fields)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyArgs passes each field as its own COPY argument, NULL as nil. lib/pq
+// escapes backslashes in strings, so a \N string would be loaded as the text
+// \N rather than NULL.
+func copyArgs(fields []string) []interface{} {
+	args := make([]interface{}, len(fields))
+	for i, v := range fields {
+		if !isNull(v) {
+			args[i] = v
+		}
+	}
+	return args
+}
+
+func (s *pgSink) Commit() error {
+	// Closing the statement ends the COPY
+	if err := s.stmt.Close(); err != nil {
+		return err
+	}
+	return s.tx.Commit()
+}
+
+func (s *pgSink) Rollback() error {
+	if s.tx == nil {
+		return nil
+	}
+	return s.tx.Rollback()
+}
+
+// Close leaves the target's pool open for the other workers.
+func (s *pgSink) Close() error {
+	return nil
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// streamTimeout bounds connecting to the broker and each publish.
+const streamTimeout = 30 * time.Second
+
+// sinkColumns names the fields of the rows the stream and local sinks write,
+// in COPY order.
+var sinkColumns []column
+
+// streamMessage is one transformed row as JSON, keyed by its UNIQUE_ID.
+type streamMessage struct {
+	key   string
+	value []byte
+}
+
+// publisher delivers messages to a broker. publish returns once the broker
+// has acknowledged all of them.
+type publisher interface {
+	publish(msgs []streamMessage) error
+	close() error
+}
+
+// newPublisher connects to the --stream URL: nats:// for a NATS server, or
+// http(s):// for a Kafka REST proxy.
+func newPublisher(rawURL string) (publisher, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "nats":
+		return dialNATS(u)
+	case "http", "https":
+		return newKafkaREST(u)
+	}
+	return nil, fmt.Errorf("unsupported --stream scheme %q, expected nats, http or https", u.Scheme)
+}
+
+// streamTopic returns --stream-topic, the --table name by default.
+func streamTopic() string {
+	if streamTopicName != "" {
+		return streamTopicName
+	}
+	return tableName
+}
+
+// checkStream connects to the broker once so a run fails before any work is
+// done when it cannot be reached.
+func checkStream() {
+	pub, err := newPublisher(streamURL)
+	if err != nil {
+		log.Fatalf("Health check of --stream: %s", err.Error())
+	}
+	pub.close()
+}
+
+// streamSink publishes each row of a batch as a JSON object of its columns,
+// keyed by UNIQUE_ID. Rows are held until Commit, so a batch rolled back
+// publishes nothing.
+type streamSink struct {
+	pub     publisher
+	keyIdx  int
+	pending []streamMessage
+}
+
+func newStreamSink() (sink, error) {
+	pub, err := newPublisher(streamURL)
+	if err != nil {
+		return nil, err
+	}
+	s := &streamSink{pub: pub, keyIdx: 1}
+	for i, c := range sinkColumns {
+		if strings.EqualFold(c.Name, "unique_id") {
+			s.keyIdx = i
+		}
+	}
+	return s, nil
+}
+
+func (s *streamSink) Begin(ctx context.Context) error {
+	s.pending = s.pending[:0]
+	return nil
+}
+
+func (s *streamSink) WriteRows(rows [][]string) error {
+	for _, fields := range rows {
+		if len(fields) != len(sinkColumns) {
+			return fmt.Errorf("row has %d fields, expected %d columns", len(fields), len(sinkColumns))
+		}
+		s.pending = append(s.pending, streamMessage{key: fields[s.keyIdx], value: rowJSON(fields)})
+	}
+	return nil
+}
+
+func (s *streamSink) Commit() error {
+	err := s.pub.publish(s.pending)
+	s.pending = s.pending[:0]
+	return err
+}
+
+func (s *streamSink) Rollback() error {
+	s.pending = s.pending[:0]
+	return nil
+}
+
+func (s *streamSink) Close() error {
+	return s.pub.close()
+}
+
+// rowJSON encodes a row as an object in column order. Numeric columns are
+// written as numbers and NULLs as null.
+func rowJSON(fields []string) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, c := range sinkColumns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(c.Name)
+		buf.Write(name)
+		buf.WriteByte(':')
+		v := fields[i]
+		switch {
+		case isNull(v):
+			buf.WriteString("null")
+		case isNumericType(c.Type) && validateValue(c.Type, v) == "":
+			buf.WriteString(jsonNumber(v))
+		default:
+			s, _ := json.Marshal(v)
+			buf.Write(s)
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes()
+}
+
+// jsonNumber writes a valid number as given, so bigints keep their
+// precision; other forms strconv accepts, like ".5", are reformatted and NaN
+// or infinities become null.
+func jsonNumber(v string) string {
+	if json.Valid([]byte(v)) {
+		return v
+	}
+	f, _ := strconv.ParseFloat(v, 64)
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "null"
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func isNumericType(dataType string) bool {
+	switch dataType {
+	case "smallint", "integer", "bigint", "numeric", "real", "double precision":
+		return true
+	}
+	return false
+}
+
+// natsPublisher speaks the core NATS protocol. A message goes to the subject
+// --stream-topic.<UNIQUE_ID>, so subscribers can pick cells with wildcards.
+// A PING after each batch confirms the server has processed it.
+type natsPublisher struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+func dialNATS(u *url.URL) (*natsPublisher, error) {
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	conn, err := net.DialTimeout("tcp", host, streamTimeout)
+	if err != nil {
+		return nil, err
+	}
+	p := &natsPublisher{conn: conn, r: bufio.NewReader(conn), w: bufio.NewWriter(conn)}
+
+	conn.SetDeadline(time.Now().Add(streamTimeout))
+	info, err := p.r.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(info, "INFO ") {
+		conn.Close()
+		return nil, fmt.Errorf("not a NATS server: %q", strings.TrimSpace(info))
+	}
+	opts := map[string]interface{}{"verbose": false, "pedantic": false, "name": applicationName, "lang": "go", "version": "1", "protocol": 1}
+	if u.User != nil {
+		opts["user"] = u.User.Username()
+		opts["pass"], _ = u.User.Password()
+	}
+	connect, _ := json.Marshal(opts)
+	fmt.Fprintf(p.w, "CONNECT %s\r\n", connect)
+	if err := p.ping(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// ping flushes what was written and waits for the server's PONG.
+func (p *natsPublisher) ping() error {
+	p.w.WriteString("PING\r\n")
+	if err := p.w.Flush(); err != nil {
+		return err
+	}
+	for {
+		line, err := p.r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		switch line = strings.TrimSpace(line); {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			p.w.WriteString("PONG\r\n")
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("NATS: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
+
+func (p *natsPublisher) publish(msgs []streamMessage) error {
+	p.conn.SetDeadline(time.Now().Add(streamTimeout))
+	topic := streamTopic()
+	for _, m := range msgs {
+		fmt.Fprintf(p.w, "PUB %s.%s %d\r\n", topic, natsToken(m.key), len(m.value))
+		p.w.Write(m.value)
+		p.w.WriteString("\r\n")
+	}
+	return p.ping()
+}
+
+func (p *natsPublisher) close() error {
+	return p.conn.Close()
+}
+
+// natsToken makes a key usable as one subject token.
+func natsToken(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '*', '>', ' ', '\t', '\r', '\n':
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+// kafkaREST publishes to Kafka through a REST proxy (Confluent REST Proxy or
+// Redpanda's HTTP proxy), one request per batch. The key is the UNIQUE_ID, so
+// a cell's messages stay in one partition.
+type kafkaREST struct {
+	endpoint string
+	client   *http.Client
+}
+
+func newKafkaREST(u *url.URL) (*kafkaREST, error) {
+	p := &kafkaREST{
+		endpoint: strings.TrimRight(u.String(), "/") + "/topics/" + url.PathEscape(streamTopic()),
+		client:   &http.Client{Timeout: streamTimeout},
+	}
+	resp, err := p.client.Get(p.endpoint)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("topic %s: %s", streamTopic(), resp.Status)
+	}
+	return p, nil
+}
+
+func (p *kafkaREST) publish(msgs []streamMessage) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	type record struct {
+		Key   string          `json:"key"`
+		Value json.RawMessage `json:"value"`
+	}
+	records := make([]record, len(msgs))
+	for i, m := range msgs {
+		records[i] = record{Key: m.key, Value: m.value}
+	}
+	body, err := json.Marshal(map[string]interface{}{"records": records})
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.client.Post(p.endpoint, "application/vnd.kafka.json.v2+json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Kafka REST proxy: %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	var result struct {
+		Offsets []struct {
+			ErrorCode *int   `json:"error_code"`
+			Error     string `json:"error"`
+		} `json:"offsets"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return err
+	}
+	for _, o := range result.Offsets {
+		if o.ErrorCode != nil {
+			return fmt.Errorf("Kafka REST proxy: %s", o.Error)
+		}
+	}
+	return nil
+}
+
+func (p *kafkaREST) close() error {
+	return nil
+}
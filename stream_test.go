@@ -0,0 +1,328 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// streamColumns are the columns of the transformed cellLine rows.
+var streamColumns = []column{{"resulttime", "timestamp without time zone"}, {"unique_id", "text"}, {"rnc", "text"},
+	{"cellname", "text"}, {"ci", "text"}, {"rrc_att", "bigint"}, {"mean_rtwp", "numeric"}}
+
+// setStream points --stream at url with --stream-topic pm for the duration of
+// a test.
+func setStream(t *testing.T, url string) {
+	t.Helper()
+	setFlag(t, "stream", url)
+	setFlag(t, "stream-topic", "pm")
+	old := sinkColumns
+	sinkColumns = streamColumns
+	t.Cleanup(func() { sinkColumns = old })
+}
+
+// streamLines scans lines into batches of batchSize and copies them with a
+// stream target's worker.
+func streamLines(t *testing.T, batchSize int, lines ...string) {
+	t.Helper()
+	resetScan(t)
+	discardOutput(t)
+	defer func(ts []*loadTarget) { targets = ts }(targets)
+	target := &loadTarget{name: "stream", open: newStreamSink}
+	targets = []*loadTarget{target}
+
+	_, got := scanLines(t, batchSize, 1, lines...)
+	C := make(chan *batch, len(got[0]))
+	for _, b := range got[0] {
+		C <- b
+	}
+	close(C)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	processBatches(&wg, target, C, streamColumns, 0)
+}
+
+type natsMessage struct {
+	subject string
+	payload string
+}
+
+// fakeNATS is a NATS server that records what is published between two PINGs
+// as one batch, and answers the PING ending a batch with -ERR when fail is set.
+type fakeNATS struct {
+	ln   net.Listener
+	fail string
+
+	mu      sync.Mutex
+	connect string
+	batches [][]natsMessage
+}
+
+func newFakeNATS(t *testing.T) *fakeNATS {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeNATS{ln: ln}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeNATS) url(user string) string {
+	return "nats://" + user + s.ln.Addr().String()
+}
+
+func (s *fakeNATS) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	fmt.Fprintf(conn, "INFO {\"server_id\":\"fake\",\"max_payload\":1048576}\r\n")
+	var pending []natsMessage
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "CONNECT "):
+			s.mu.Lock()
+			s.connect = strings.TrimPrefix(line, "CONNECT ")
+			s.mu.Unlock()
+		case strings.HasPrefix(line, "PUB "):
+			args := strings.Fields(line)
+			n, _ := strconv.Atoi(args[len(args)-1])
+			payload := make([]byte, n+2)
+			if _, err := io.ReadFull(r, payload); err != nil {
+				return
+			}
+			pending = append(pending, natsMessage{args[1], string(payload[:n])})
+		case line == "PING":
+			if len(pending) > 0 && s.fail != "" {
+				fmt.Fprintf(conn, "-ERR '%s'\r\n", s.fail)
+				pending = nil
+				continue
+			}
+			if len(pending) > 0 {
+				s.mu.Lock()
+				s.batches = append(s.batches, pending)
+				s.mu.Unlock()
+				pending = nil
+			}
+			fmt.Fprintf(conn, "PONG\r\n")
+		}
+	}
+}
+
+type kafkaRecord struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+// fakeKafka is a Kafka REST proxy with the single topic pm. It records the
+// records of every POST as one batch, and fails them with an error_code when
+// fail is set.
+type fakeKafka struct {
+	*httptest.Server
+	fail string
+
+	mu          sync.Mutex
+	contentType string
+	batches     [][]kafkaRecord
+}
+
+func newFakeKafka(t *testing.T) *fakeKafka {
+	s := &fakeKafka{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/topics/pm" {
+			http.Error(w, `{"error_code":40401,"message":"Topic not found"}`, http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodGet {
+			fmt.Fprint(w, `{"name":"pm"}`)
+			return
+		}
+		var body struct {
+			Records []kafkaRecord `json:"records"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		offsets := make([]map[string]interface{}, len(body.Records))
+		for i := range offsets {
+			offsets[i] = map[string]interface{}{"partition": 0, "offset": i, "error_code": nil, "error": nil}
+			if s.fail != "" {
+				offsets[i]["error_code"], offsets[i]["error"] = 50002, s.fail
+			}
+		}
+		s.mu.Lock()
+		s.contentType = r.Header.Get("Content-Type")
+		if s.fail == "" {
+			s.batches = append(s.batches, body.Records)
+		}
+		s.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{"offsets": offsets})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestRowJSON(t *testing.T) {
+	old := sinkColumns
+	defer func() { sinkColumns = old }()
+	sinkColumns = []column{{"unique_id", "text"}, {"cellname", "text"}, {"rrc_att", "bigint"}, {"rrc_succ", "bigint"},
+		{"mean_rtwp", "numeric"}, {"ratio", "double precision"}, {"big", "bigint"}, {"bad", "bigint"}}
+
+	got := string(rowJSON([]string{"101CELLA", `CELL "A"`, `\N`, "", ".5", "NaN", "9007199254740993", "abc"}))
+	want := `{"unique_id":"101CELLA","cellname":"CELL \"A\"","rrc_att":null,"rrc_succ":null,` +
+		`"mean_rtwp":0.5,"ratio":null,"big":9007199254740993,"bad":"abc"}`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	if !json.Valid([]byte(got)) {
+		t.Errorf("%s is not valid JSON", got)
+	}
+}
+
+func TestNATSStream(t *testing.T) {
+	nats := newFakeNATS(t)
+	setStream(t, nats.url("loader:secret@"))
+
+	streamLines(t, 2, cellLine(0, "CELLA"), cellLine(0, "CELLB"), cellLine(1, "CELLA"), cellLine(1, "CELLB"), cellLine(2, "CELL.C"))
+
+	var connect map[string]interface{}
+	if err := json.Unmarshal([]byte(nats.connect), &connect); err != nil || connect["user"] != "loader" || connect["pass"] != "secret" {
+		t.Errorf("CONNECT %s, want the URL's user and password", nats.connect)
+	}
+	var sizes []int
+	for _, b := range nats.batches {
+		sizes = append(sizes, len(b))
+	}
+	if !reflect.DeepEqual(sizes, []int{2, 2, 1}) {
+		t.Fatalf("acknowledged batches of %v messages, want [2 2 1]", sizes)
+	}
+	first := nats.batches[0][0]
+	if first.subject != "pm.1ACELLA" {
+		t.Errorf("subject %q, want pm.1ACELLA", first.subject)
+	}
+	if want := `{"resulttime":"2024-03-01 00:00:00","unique_id":"1ACELLA","rnc":"RNC01","cellname":"CELLA","ci":"1A","rrc_att":10,"mean_rtwp":9}`; first.payload != want {
+		t.Errorf("payload %s, want %s", first.payload, want)
+	}
+	if got := nats.batches[2][0].subject; got != "pm.1CCELL_C" {
+		t.Errorf("subject %q, want the dot in the key replaced", got)
+	}
+}
+
+func TestNATSStreamError(t *testing.T) {
+	nats := newFakeNATS(t)
+	nats.fail = "Permissions Violation for Publish to pm.1ACELLA"
+	setStream(t, nats.url(""))
+
+	out, err := newStreamSink()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	out.Begin(context.Background())
+	if err := out.WriteRows([][]string{{"2024-03-01 00:00:00", "1ACELLA", "RNC01", "CELLA", "1A", "10", "9"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := out.Commit(); err == nil || !strings.Contains(err.Error(), "Permissions Violation") {
+		t.Errorf("commit returned %v, want the server's -ERR", err)
+	}
+}
+
+func TestKafkaRESTStream(t *testing.T) {
+	kafka := newFakeKafka(t)
+	setStream(t, kafka.URL+"/")
+
+	streamLines(t, 2, cellLine(0, "CELLA"), cellLine(0, "CELLB"), cellLine(1, "CELLA"))
+
+	if kafka.contentType != "application/vnd.kafka.json.v2+json" {
+		t.Errorf("content type %q", kafka.contentType)
+	}
+	if len(kafka.batches) != 2 || len(kafka.batches[0]) != 2 || len(kafka.batches[1]) != 1 {
+		t.Fatalf("got %d requests, want one per batch of 2 and 1 records", len(kafka.batches))
+	}
+	r := kafka.batches[1][0]
+	if r.Key != "1ACELLA" {
+		t.Errorf("key %q, want 1ACELLA", r.Key)
+	}
+	var value map[string]interface{}
+	if err := json.Unmarshal(r.Value, &value); err != nil || value["resulttime"] != "2024-03-01 01:00:00" || value["rrc_att"] != 10.0 {
+		t.Errorf("value %s, want the row as an object", r.Value)
+	}
+}
+
+func TestKafkaRESTErrors(t *testing.T) {
+	kafka := newFakeKafka(t)
+	row := []string{"2024-03-01 00:00:00", "1ACELLA", "RNC01", "CELLA", "1A", "10", "9"}
+
+	setStream(t, kafka.URL)
+	setFlag(t, "stream-topic", "missing")
+	if _, err := newStreamSink(); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("unknown topic: got %v, want a 404", err)
+	}
+
+	setFlag(t, "stream-topic", "pm")
+	kafka.fail = "Leader not available"
+	out, err := newStreamSink()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.Begin(context.Background())
+	out.WriteRows([][]string{row})
+	if err := out.Commit(); err == nil || !strings.Contains(err.Error(), "Leader not available") {
+		t.Errorf("commit returned %v, want the record's error", err)
+	}
+}
+
+// TestStreamRollback checks that the rows of a rolled back batch are never
+// published.
+func TestStreamRollback(t *testing.T) {
+	kafka := newFakeKafka(t)
+	setStream(t, kafka.URL)
+
+	out, err := newStreamSink()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.Begin(context.Background())
+	out.WriteRows([][]string{{"2024-03-01 00:00:00", "1ACELLA", "RNC01", "CELLA", "1A", "10", "9"}})
+	out.Rollback()
+	out.Begin(context.Background())
+	out.WriteRows([][]string{{"2024-03-01 01:00:00", "1BCELLB", "RNC01", "CELLB", "1B", "10", "9"}})
+	if err := out.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if len(kafka.batches) != 1 || len(kafka.batches[0]) != 1 || kafka.batches[0][0].Key != "1BCELLB" {
+		t.Errorf("published %v, want only the committed row", kafka.batches)
+	}
+
+	// A rolled back batch alone makes no request at all
+	out.Begin(context.Background())
+	out.WriteRows([][]string{{"2024-03-01 02:00:00", "1ACELLA", "RNC01", "CELLA", "1A", "10", "9"}})
+	out.Rollback()
+	if err := out.Commit(); err != nil || len(kafka.batches) != 1 {
+		t.Errorf("empty commit: %v with %d requests, want none", err, len(kafka.batches))
+	}
+}
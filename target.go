@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// loadTarget is where the run loads into: the database of --connection with
+// --db-name or the --sink file, the database of each --target, then the
+// --stream. Every target
+// has its own workers, and a batch is committed to each independently.
+type loadTarget struct {
+	index    int
+	name     string
+	database bool   // a PostgreSQL database, which gets the setup and post-load phases
+	connect  string // connection string of a database
+	// open returns a sink for one of the target's workers
+	open func() (sink, error)
+	// close releases what the sinks of a local target share, if set
+	close func()
+
+	pool     *sqlx.DB
+	poolOnce sync.Once
+
+	rows int64 // rows committed
+
+	mu  sync.Mutex
+	err interface{} // why the target's workers stopped, nil while it is healthy
+}
+
+var (
+	targets []*loadTarget
+	// current is the database target that connect() and the phases run
+	// outside the workers use, nil when the run has no database
+	current *loadTarget
+)
+
+// targetList collects the repeated --target flags.
+type targetList []string
+
+func (l *targetList) String() string {
+	return strings.Join(*l, "; ")
+}
+
+func (l *targetList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// setupTargets creates the primary target, one per --target and one for
+// --stream. A --target without a dbname loads into --db-name.
+func setupTargets() {
+	if sinkName == sinkPostgres {
+		targets = []*loadTarget{newDatabaseTarget(0, targetName(connKeywords(postgresConnect), dbName), getConnectString())}
+	} else {
+		targets = []*loadTarget{newLocalTarget()}
+	}
+	for _, c := range extraTargets {
+		c = connKeywords(c)
+		targets = append(targets, newDatabaseTarget(len(targets), targetName(c, dbName), connectString("dbname="+connValue(dbName)+" "+c)))
+	}
+	if streamURL != "" {
+		name := streamURL
+		if u, err := url.Parse(streamURL); err == nil {
+			name = u.Redacted()
+		}
+		targets = append(targets, &loadTarget{index: len(targets), name: name, open: newStreamSink})
+	}
+	current = primaryDatabase()
+}
+
+// primaryDatabase returns the first database target, or nil.
+func primaryDatabase() *loadTarget {
+	for _, t := range targets {
+		if t.database {
+			return t
+		}
+	}
+	return nil
+}
+
+func newDatabaseTarget(index int, name, connect string) *loadTarget {
+	t := &loadTarget{index: index, name: name, database: true, connect: connect}
+	t.open = func() (sink, error) {
+		return &pgSink{db: t.db()}, nil
+	}
+	return t
+}
+
+// targetName labels a target by the host and database of its key=value
+// connection string, leaving out the credentials.
+func targetName(conn, db string) string {
+	host, port := "localhost", ""
+	for _, kv := range strings.Fields(conn) {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value := strings.Trim(parts[1], "'")
+		switch parts[0] {
+		case "host":
+			host = value
+		case "port":
+			port = value
+		case "dbname":
+			db = value
+		}
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	return host + "/" + db
+}
+
+// eachTarget runs fn with every database target in turn as the current one.
+func eachTarget(fn func()) {
+	for _, t := range targets {
+		if t.database {
+			current = t
+			fn()
+		}
+	}
+	current = primaryDatabase()
+}
+
+// fanOut reports whether the run loads into more than one target.
+func fanOut() bool {
+	return len(targets) > 1
+}
+
+// failed returns why the target's workers stopped, or nil.
+func (t *loadTarget) failed() interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+// recoverWorker turns a worker's panic into a failure of its target alone, so
+// the other targets keep loading. The remaining batches for the target are
+// drained without being copied.
+func (t *loadTarget) recoverWorker(C chan *batch) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	t.mu.Lock()
+	if t.err == nil {
+		t.err = r
+		fmt.Fprintf(os.Stderr, "[TARGET] %s failed, no further batches are copied to it: %v\n", t.name, r)
+	}
+	t.mu.Unlock()
+	for b := range C {
+		b.release()
+	}
+}
+
+// summary prints how many rows the target received and whether it is
+// complete.
+func (t *loadTarget) summary() {
+	status := "complete"
+	if err := t.failed(); err != nil {
+		status = fmt.Sprintf("failed: %v", err)
+	} else if !allCommitted(t.index) {
+		status = "incomplete"
+	}
+	fmt.Printf("[TARGET] %s: COPY %d, %s\n", t.name, atomic.LoadInt64(&t.rows), status)
+}
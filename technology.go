@@ -0,0 +1,130 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"strings"
+)
+
+// technology is a radio technology profile: the key columns and UNIQUE_ID of
+// its export, its counter catalogue and rollup definitions and the default
+// names of its tables. Scan, validation and COPY are shared by all of them.
+type technology struct {
+	name string
+	// keys are the leading columns in COPY order: time, UNIQUE_ID, network
+	// element, cell name and cell id
+	keys []column
+	// uniqueID derives UNIQUE_ID from the split input line, which has the
+	// time, network element, cell name and cell id first
+	uniqueID            func(sp []string) string
+	counters            []column
+	kpis                []kpi
+	successAttemptPairs [][2]string
+
+	hourlyTable    string
+	dailyTable     string
+	kpiHourlyTable string
+	kpiDailyTable  string
+	cellTable      string
+}
+
+var technologies = map[string]*technology{
+	"2g": {
+		name:                "2g",
+		keys:                huawei2GKeys,
+		uniqueID:            ciCellname,
+		counters:            huawei2GCounters,
+		kpis:                huawei2GKPIs,
+		successAttemptPairs: huawei2GSuccessAttemptPairs,
+		hourlyTable:         "counter_2g_hourly",
+		dailyTable:          "counter_2g_daily",
+		kpiHourlyTable:      "kpi_2g_hourly",
+		kpiDailyTable:       "kpi_2g_daily",
+		cellTable:           "cell_2g",
+	},
+	"3g": {
+		name:                "3g",
+		keys:                huawei3GKeys,
+		uniqueID:            ciCellname,
+		counters:            huawei3GCounters,
+		kpis:                defaultKPIs,
+		successAttemptPairs: successAttemptPairs,
+		hourlyTable:         "counter_3g_hourly",
+		dailyTable:          "counter_3g_daily",
+		kpiHourlyTable:      "kpi_3g_hourly",
+		kpiDailyTable:       "kpi_3g_daily",
+		cellTable:           "cell_3g",
+	},
+	"4g": {
+		name: "4g",
+		keys: huawei4GKeys,
+		// Local cell ids are only unique within an eNodeB
+		uniqueID:            func(sp []string) string { return sp[1] + "_" + sp[3] },
+		counters:            huawei4GCounters,
+		kpis:                huawei4GKPIs,
+		successAttemptPairs: huawei4GSuccessAttemptPairs,
+		hourlyTable:         "counter_4g_hourly",
+		dailyTable:          "counter_4g_daily",
+		kpiHourlyTable:      "kpi_4g_hourly",
+		kpiDailyTable:       "kpi_4g_daily",
+		cellTable:           "cell_4g",
+	},
+}
+
+// tech is the technology profile selected by --profile.
+var tech = technologies["3g"]
+
+// ciCellname is the Huawei UNIQUE_ID: the cell id followed by the cell name.
+func ciCellname(sp []string) string {
+	return sp[3] + sp[2]
+}
+
+// ne is the network element column: rnc, bsc or enodeb.
+func (t *technology) ne() string {
+	return t.keys[2].Name
+}
+
+// cellID is the column identifying a cell within its network element.
+func (t *technology) cellID() string {
+	return t.keys[4].Name
+}
+
+// keyList is the comma-separated key columns after the time column.
+func (t *technology) keyList() string {
+	names := make([]string, 0, len(t.keys)-1)
+	for _, k := range t.keys[1:] {
+		names = append(names, k.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// selectTechnology applies --profile. Table flags that were not given on the
+// command line take the profile's default names.
+func selectTechnology() {
+	t, ok := technologies[strings.ToLower(profileName)]
+	if !ok {
+		log.Fatalf("Unknown --profile %q, expected 2g, 3g or 4g", profileName)
+	}
+	tech = t
+
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	defaults := []struct {
+		flag  string
+		value *string
+		name  string
+	}{
+		{"hourly-table", &hourlyTable, t.hourlyTable},
+		{"daily-table", &dailyTable, t.dailyTable},
+		{"kpi-hourly-table", &kpiHourlyTable, t.kpiHourlyTable},
+		{"kpi-daily-table", &kpiDailyTable, t.kpiDailyTable},
+		{"cell-table", &cellTable, t.cellTable},
+	}
+	for _, d := range defaults {
+		if !set[d.flag] {
+			*d.value = d.name
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// column is a destination table column as reported by information_schema.
+type column struct {
+	Name string `db:"column_name"`
+	Type string `db:"data_type"`
+}
+
+// timestampLayouts are the resulttime formats seen in PM exports.
+var timestampLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	time.RFC3339,
+	"01/02/2006 15:04:05",
+	"01/02/2006 15:04",
+}
+
+// fieldError describes a single value that does not fit its column.
+type fieldError struct {
+	Column string
+	Value  string
+	Reason string
+}
+
+func (e *fieldError) Error() string {
+	return fmt.Sprintf("column %s: %s (value %q)", e.Column, e.Reason, e.Value)
+}
+
+// loadTableColumns returns the destination columns in COPY order, read from
+// --schema-file when given and from information_schema otherwise.
+func loadTableColumns() []column {
+	var cols []column
+	if len(schemaFile) > 0 {
+		cols = readSchemaFile(schemaFile)
+	} else if current == nil {
+		// Without a database the columns are named by --columns or --header,
+		// and only their number is checked
+		if columns == "" {
+			log.Fatalf("--sink %s needs --schema-file, --columns or --header to name the columns", sinkName)
+		}
+		for _, name := range strings.Split(columns, ",") {
+			cols = append(cols, column{Name: strings.TrimSpace(name), Type: "text"})
+		}
+	} else {
+		db := connect()
+		err := db.Select(&cols, `SELECT column_name, data_type FROM information_schema.columns
+			WHERE table_schema = $1 AND table_name = $2 ORDER BY ordinal_position`, schemaName, tableName)
+		if err != nil {
+			panic(err)
+		}
+	}
+	if len(cols) == 0 {
+		log.Fatalf("No columns found for %s", getFullTableName())
+	}
+
+	if columns == "" {
+		return cols
+	}
+
+	// Only the columns listed in --columns are present, in that order
+	byName := make(map[string]column, len(cols))
+	for _, c := range cols {
+		byName[strings.ToLower(c.Name)] = c
+	}
+	var selected []column
+	for _, name := range strings.Split(columns, ",") {
+		c, ok := byName[strings.ToLower(strings.TrimSpace(name))]
+		if !ok && evolveSchemaMode {
+			// Not added yet, --evolve-schema creates it before the load
+			c = column{Name: strings.TrimSpace(name), Type: evolveType}
+		} else if !ok {
+			log.Fatalf("Column %s from --columns does not exist in %s", name, getFullTableName())
+		}
+		selected = append(selected, c)
+	}
+	return selected
+}
+
+// readSchemaFile reads "column_name,data_type" lines, e.g. the output of
+// \copy (SELECT column_name, data_type FROM information_schema.columns ...) TO ... CSV
+func readSchemaFile(path string) []column {
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	var cols []column
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sp := strings.SplitN(line, ",", 2)
+		if len(sp) != 2 {
+			log.Fatalf("Invalid schema file line %q, expected column_name,data_type", line)
+		}
+		cols = append(cols, column{Name: strings.TrimSpace(sp[0]), Type: strings.TrimSpace(sp[1])})
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatalf("Error reading schema file: %s", err.Error())
+	}
+	return cols
+}
+
+func isNull(v string) bool {
+	return v == "" || v == `\N`
+}
+
+func parseTimestamp(v string) (time.Time, error) {
+	var err error
+	for _, layout := range timestampLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, v); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// validateValue checks that v can be stored in a column of type dataType.
+func validateValue(dataType, v string) string {
+	if isNull(v) {
+		return ""
+	}
+	switch {
+	case strings.HasPrefix(dataType, "timestamp"), dataType == "date":
+		if _, err := parseTimestamp(v); err != nil {
+			return "not a timestamp"
+		}
+	case dataType == "smallint", dataType == "integer", dataType == "bigint":
+		if _, err := strconv.ParseInt(v, 10, 64); err != nil {
+			return "not an integer"
+		}
+	case dataType == "numeric", dataType == "real", dataType == "double precision":
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return "not a number"
+		}
+	}
+	return ""
+}
+
+var (
+	rejectMu    sync.Mutex
+	rejectOut   *os.File
+	rejectCount int64
+)
+
+// openRejects opens the reject stream selected by --reject-file.
+func openRejects() {
+	if len(rejectFile) == 0 {
+		rejectOut = os.Stderr
+		return
+	}
+	var err error
+	rejectOut, err = os.OpenFile(rejectFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func closeRejects() {
+	if rejectOut != nil && rejectOut != os.Stderr {
+		rejectOut.Close()
+	}
+}
+
+// reject writes a row that failed validation to the reject stream as
+// tab-separated line number, column, offending value, reason and the raw line.
+func reject(lineNo int64, line string, err error) {
+	atomic.AddInt64(&rejectCount, 1)
+
+	col, value, reason := "", "", err.Error()
+	if fe, ok := err.(*fieldError); ok {
+		col, value, reason = fe.Column, fe.Value, fe.Reason
+	}
+
+	rejectMu.Lock()
+	defer rejectMu.Unlock()
+	if _, err := fmt.Fprintf(rejectOut, "%d\t%s\t%s\t%s\t%s\n", lineNo, col, value, reason, line); err != nil {
+		log.Println(err)
+	}
+}
+
+// validateRow checks a transformed row against the destination columns.
+func validateRow(fields []string, cols []column) error {
+	if len(fields) != len(cols) {
+		return fmt.Errorf("row has %d fields, %s has %d columns", len(fields), getFullTableName(), len(cols))
+	}
+	for i, c := range cols {
+		if reason := validateValue(c.Type, fields[i]); reason != "" {
+			return &fieldError{Column: c.Name, Value: fields[i], Reason: reason}
+		}
+	}
+	return nil
+}
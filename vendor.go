@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// vendorProfile describes how one vendor's PM export is read: how the input is
+// parsed, how UNIQUE_ID is derived and how its counters map to the catalogue.
+type vendorProfile struct {
+	name string
+	// parse reads the whole input into rows keyed by time and cell. It is nil
+	// for vendors whose export already is delimited text, one row per line.
+	parse func(r io.Reader, counters *counterMapping) (*pmRows, error)
+	// transform splits one delimited line into the COPY fields
+	transform func(line, sep string) ([]string, error)
+	// counterMap maps vendor counter names to catalogue counter names
+	counterMap map[string]string
+}
+
+var vendorProfiles = map[string]*vendorProfile{
+	"huawei":   {name: "huawei", transform: transformLine},
+	"ericsson": {name: "ericsson", parse: parseEricsson, transform: splitLine, counterMap: ericssonCounterMap},
+	"nokia":    {name: "nokia", parse: parseNokia, transform: splitLine, counterMap: nokiaCounterMap},
+}
+
+// profile is the vendor profile selected by --vendor.
+var profile = vendorProfiles["huawei"]
+
+func selectVendor() {
+	p, ok := vendorProfiles[strings.ToLower(vendorName)]
+	if !ok {
+		names := make([]string, 0, len(vendorProfiles))
+		for n := range vendorProfiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		log.Fatalf("Unknown --vendor %q, expected one of %s", vendorName, strings.Join(names, ", "))
+	}
+	profile = p
+}
+
+// splitLine splits a line rendered by a vendor parser, which already carries
+// its UNIQUE_ID.
+func splitLine(line, sep string) ([]string, error) {
+	return strings.Split(line, sep), nil
+}
+
+// counterMapping renames vendor counters to catalogue counters. Without
+// --harmonize every counter keeps its vendor name, for vendor-specific tables.
+type counterMapping struct {
+	names    map[string]string
+	unmapped map[string]bool
+}
+
+// loadCounterMapping merges the profile's built-in mapping with --counter-map,
+// a file of vendor_counter,catalogue_counter lines. Harmonizing without any
+// mapping would skip every counter, so that stops the load.
+func loadCounterMapping() *counterMapping {
+	m := &counterMapping{names: make(map[string]string), unmapped: make(map[string]bool)}
+	for vendorCounter, counter := range profile.counterMap {
+		m.names[strings.ToLower(vendorCounter)] = counter
+	}
+	if len(counterMapFile) > 0 {
+		for _, c := range readSchemaFile(counterMapFile) {
+			m.names[strings.ToLower(c.Name)] = c.Type
+		}
+	}
+	if harmonize && len(m.names) == 0 {
+		log.Fatalf("--vendor %s has no built-in counter mapping; give --counter-map, or --harmonize=false to keep the vendor counter names", profile.name)
+	}
+	return m
+}
+
+// column returns the destination column of a vendor counter, or "" when
+// harmonizing and the counter has no mapping.
+func (m *counterMapping) column(vendorCounter string) string {
+	if !harmonize {
+		return counterIdent(vendorCounter)
+	}
+	if c, ok := m.names[strings.ToLower(vendorCounter)]; ok {
+		return c
+	}
+	m.unmapped[vendorCounter] = true
+	return ""
+}
+
+// pmCell identifies the cell a vendor record was measured on.
+type pmCell struct {
+	uniqueID string
+	rnc      string
+	cellname string
+	ci       string
+}
+
+type pmRow struct {
+	time   time.Time
+	cell   pmCell
+	values map[int]string
+}
+
+// pmRows gathers the counters of a vendor file into one row per time and cell,
+// since vendors spread a cell's counters over several measurement blocks.
+type pmRows struct {
+	mapping  *counterMapping
+	counters []string
+	index    map[string]int
+	rows     []*pmRow
+	byKey    map[string]*pmRow
+}
+
+func newPMRows(mapping *counterMapping) *pmRows {
+	return &pmRows{mapping: mapping, index: make(map[string]int), byKey: make(map[string]*pmRow)}
+}
+
+// set records one counter value of a cell.
+func (p *pmRows) set(t time.Time, cell pmCell, vendorCounter, value string) {
+	name := p.mapping.column(vendorCounter)
+	if name == "" {
+		return
+	}
+	idx, ok := p.index[strings.ToLower(name)]
+	if !ok {
+		idx = len(p.counters)
+		p.index[strings.ToLower(name)] = idx
+		p.counters = append(p.counters, name)
+	}
+
+	key := t.Format(time.RFC3339) + "|" + cell.uniqueID
+	row, ok := p.byKey[key]
+	if !ok {
+		row = &pmRow{time: t, cell: cell, values: make(map[int]string)}
+		p.byKey[key] = row
+		p.rows = append(p.rows, row)
+	}
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, "nil") { // 3GPP marker for a missing result
+		value = ""
+	}
+	row.values[idx] = value
+}
+
+// render lays the rows out as sep-delimited lines in the column order it
+// returns. Counters a row has no value for are written as \N.
+func (p *pmRows) render(sep string) ([]string, []byte) {
+	header := []string{timeColumn}
+	for _, k := range tech.keys[1:] {
+		header = append(header, k.Name)
+	}
+	header = append(header, p.counters...)
+
+	var b bytes.Buffer
+	fields := make([]string, len(header))
+	for _, r := range p.rows {
+		fields[0] = r.time.Format("2006-01-02 15:04:05")
+		fields[1], fields[2], fields[3], fields[4] = r.cell.uniqueID, r.cell.rnc, r.cell.cellname, r.cell.ci
+		for i := range p.counters {
+			v, ok := r.values[i]
+			if !ok || v == "" {
+				v = `\N`
+			}
+			fields[5+i] = v
+		}
+		b.WriteString(strings.Join(fields, sep))
+		b.WriteByte('\n')
+	}
+	return header, b.Bytes()
+}
+
+// openInput returns a scanner over the input lines, and for vendors with their
+// own format and Parquet the column names of those lines. Such input is
+// rendered as --split delimited lines, so the rest of the load is shared.
+func openInput() (lineSource, []string, func()) {
+	if inputFormat == inputFormatParquet {
+		src := openParquet()
+		profile = parquetProfile
+		return src, src.columns, src.Close
+	}
+
+	var r io.Reader = os.Stdin
+	closeInput := func() {}
+	if len(fromFile) > 0 {
+		file, err := os.Open(fromFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		r = file
+		closeInput = func() { file.Close() }
+	}
+	if profile.parse == nil {
+		return bufio.NewScanner(r), nil, closeInput
+	}
+	defer closeInput()
+
+	mapping := loadCounterMapping()
+	rows, err := profile.parse(r, mapping)
+	if err != nil {
+		log.Fatalf("Error parsing %s input: %s", profile.name, err.Error())
+	}
+	if len(mapping.unmapped) > 0 {
+		names := make([]string, 0, len(mapping.unmapped))
+		for n := range mapping.unmapped {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(os.Stderr, "[VENDOR] %d %s counter(s) not in the counter map skipped: %s\n", len(names), profile.name, strings.Join(names, ", "))
+	}
+
+	header, data := rows.render(splitSeparator())
+	return bufio.NewScanner(bytes.NewReader(data)), header, func() {}
+}
+
+// cellFromPairs finds the values of the given keys in a distinguished name
+// made of key=value (Ericsson) or KEY-value (Nokia) parts.
+func cellFromPairs(dn, partSep, kvSep string) map[string]string {
+	parts := make(map[string]string)
+	for _, p := range strings.Split(dn, partSep) {
+		kv := strings.SplitN(strings.TrimSpace(p), kvSep, 2)
+		if len(kv) == 2 {
+			parts[strings.ToLower(kv[0])] = kv[1]
+		}
+	}
+	return parts
+}
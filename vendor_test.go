@@ -0,0 +1,88 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const ericssonXML = `<?xml version="1.0" encoding="UTF-8"?>
+<measCollecFile xmlns="http://www.3gpp.org/ftp/specs/archive/32_series/32.435#measCollec">
+  <fileHeader><fileSender localDn="SubNetwork=ONRM_ROOT,MeContext=RNC01"/></fileHeader>
+  <measData>
+    <managedElement localDn="SubNetwork=ONRM_ROOT,MeContext=RNC01"/>
+    <measInfo>
+      <granPeriod duration="PT3600S" endTime="2024-03-01T11:00:00+00:00"/>
+      <measTypes>pmTotNoRrcConnectReq</measTypes>
+      <measValue measObjLdn="ManagedElement=1,RncFunction=1,UtranCell=CELLA"><measResults>10</measResults></measValue>
+      <measValue measObjLdn="ManagedElement=1,RncFunction=1,UtranCell=CELLB"><measResults>20</measResults></measValue>
+    </measInfo>
+  </measData>
+</measCollecFile>`
+
+func TestParseEricssonCellID(t *testing.T) {
+	mapping := &counterMapping{names: map[string]string{"pmtotnorrcconnectreq": "VSRRCAttConnEstabSum"}, unmapped: make(map[string]bool)}
+	rows, err := parseEricsson(strings.NewReader(ericssonXML), mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, data := rows.render(",")
+	want := "2024-03-01 10:00:00,RNC01/CELLA,RNC01,CELLA,CELLA,10\n2024-03-01 10:00:00,RNC01/CELLB,RNC01,CELLB,CELLB,20\n"
+	if string(data) != want {
+		t.Errorf("got\n%s\nwant\n%s", data, want)
+	}
+}
+
+// TestParseNokiaCSVHarmonized loads a NetAct report with the built-in Nokia
+// mapping; the unmapped counter is skipped.
+func TestParseNokiaCSVHarmonized(t *testing.T) {
+	defer func(p *vendorProfile) { profile = p }(profile)
+	profile = vendorProfiles["nokia"]
+	report := "PERIOD_START_TIME;RNC name;WCEL name;CI;RRC_CONN_STP_ATT;RRC_CONN_ACC_COMP;CELL_ADD_REQ_ON_SHO_FOR_RT;M1000C1\n" +
+		"03.01.2024 10:00:00;RNC12;CELLA;101;10;9;;5\n"
+	mapping := loadCounterMapping()
+	rows, err := parseNokia(strings.NewReader(report), mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, data := rows.render(",")
+	if got, want := strings.Join(header, ","), "resulttime,unique_id,rnc,cellname,ci,VSRRCAttConnEstabSum,RRCSuccConnEstabsum,VSSHOAttRLAdd"; got != want {
+		t.Errorf("header %s, want %s", got, want)
+	}
+	if want := "2024-03-01 10:00:00,RNC12/101,RNC12,CELLA,101,10,9,\\N\n"; string(data) != want {
+		t.Errorf("got\n%s\nwant\n%s", data, want)
+	}
+	if !mapping.unmapped["M1000C1"] {
+		t.Errorf("unmapped counters %v, want M1000C1", mapping.unmapped)
+	}
+}
+
+const nokiaOMeS = `<?xml version="1.0" encoding="UTF-8"?>
+<OMeS>
+  <PMSetup startTime="2024-03-01T10:00:00.000+07:00:00" interval="60">
+    <PMMOResult>
+      <MO><DN>PLMN-PLMN/RNC-12/WBTS-3/WCEL-4</DN></MO>
+      <PMTarget measurementType="RRC"><M1001C0>10</M1001C0><M1001C1>2</M1001C1></PMTarget>
+    </PMMOResult>
+    <PMMOResult>
+      <MO><DN>PLMN-PLMN/RNC-12/WBTS-3</DN></MO>
+      <PMTarget measurementType="RRC"><M1001C0>99</M1001C0></PMTarget>
+    </PMMOResult>
+  </PMSetup>
+</OMeS>`
+
+func TestParseNokiaOMeS(t *testing.T) {
+	mapping := &counterMapping{names: map[string]string{"m1001c0": "VSRRCAttConnEstabSum"}, unmapped: make(map[string]bool)}
+	rows, err := parseNokia(strings.NewReader(nokiaOMeS), mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, data := rows.render(",")
+	if got := header[len(header)-1]; got != "VSRRCAttConnEstabSum" {
+		t.Errorf("counter column %s, want VSRRCAttConnEstabSum", got)
+	}
+	// Times keep the zone of the file, and the WBTS result is not a cell
+	want := "2024-03-01 10:00:00,12/4,12,PLMN-PLMN/RNC-12/WBTS-3/WCEL-4,4,10\n"
+	if string(data) != want {
+		t.Errorf("got\n%s\nwant\n%s", data, want)
+	}
+}